@@ -3,7 +3,7 @@ module github.com/jonpalmisc/atto
 go 1.13
 
 require (
-	github.com/mattn/go-runewidth v0.0.7 // indirect
-	github.com/nsf/termbox-go v0.0.0-20191229070316-58d4fcbce2a7
+	github.com/mattn/go-runewidth v0.0.9
+	github.com/nsf/termbox-go v1.1.1
 	gopkg.in/yaml.v2 v2.2.7
 )
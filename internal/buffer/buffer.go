@@ -1,7 +1,6 @@
 package buffer
 
 import (
-	"bufio"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,88 +9,122 @@ import (
 
 	"github.com/jonpalmisc/atto/internal/config"
 	"github.com/jonpalmisc/atto/internal/support"
+	"github.com/jonpalmisc/atto/internal/syntax"
 )
 
 // IsInsertable tells whether a character is insertable into the buffer or not.
 func IsInsertable(c rune) bool {
-	switch unicode.ToLower(c) {
-	case '!', '@', '#', '$', '%', '^', '&', '*', '(', ')',
-		'1', '2', '3', '4', '5', '6', '7', '8', '9', '0',
-		'q', 'w', 'e', 'r', 't', 'y', 'u', 'i', 'o', 'p',
-		'a', 's', 'd', 'f', 'g', 'h', 'j', 'k', 'l',
-		'z', 'x', 'c', 'v', 'b', 'n', 'm',
-		'`', '~', '-', '_', '=', '+', '\t', '[', '{', ']', '}', '\\', '|',
-		';', ':', '\'', '"', ',', '<', '.', '>', '/', '?', ' ':
-		return true
-	default:
-		return false
-	}
+	return c == '\t' || unicode.IsPrint(c)
 }
 
 // Buffer represents a text buffer corresponding to a file.
 type Buffer struct {
 	Config *config.Config
 
-	// The path to the file and its type.
+	// The path to the file, its type, and the syntax used to highlight it.
 	Path     string
 	FileType support.FileType
+	Syntax   *syntax.Syntax
 
-	// The buffer's lines and condition.
+	// The buffer's lines and condition, and the format of its file.
 	Lines      []Line
+	Format     Format
 	IsDirty    bool
 	IsReadOnly bool
 
+	// The version of the file the buffer was last loaded from or saved to.
+	stamp fileStamp
+
+	// The buffer's edit history, used for undoing and redoing edits.
+	History History
+
+	// The path to a swap file left behind for the buffer's file, which has not
+	// been recovered or discarded yet. The buffer's own swap file, if it wrote
+	// one, and whether the buffer changed since it was written.
+	Swap        string
+	swapFile    string
+	isSwapStale bool
+
+	// The view the buffer is edited through, which belongs to the pane the
+	// buffer was last focused in. Cursor movement and edits act on this view,
+	// while other panes showing the buffer keep their own.
+	*View
+}
+
+// View is the state of a view onto a buffer: the cursor, the selection and the
+// scroll offsets.
+type View struct {
+
 	// The cursor's position. The Y value must always be decremented by one when
 	// accessing buffer elements since the editor's title bar occupies the first
-	// row of the screen. CursorDX is the cursor's X position, with compensation
-	// for extra space introduced by rendering tabs.
+	// row of the screen. The X value is a rune index into the focused line.
+	// CursorDX is the cursor's display column, with compensation for extra
+	// space introduced by rendering tabs and wide characters.
 	CursorX  int
 	CursorDX int
 	CursorY  int
 
-	// The viewport's column and row offsets.
-	OffsetX int
-	OffsetY int
+	// The selection's anchor and whether a selection is active. The selection
+	// spans from the anchor to the cursor. When the mark is set, the selection
+	// is extended by regular cursor movement instead of being cleared.
+	Anchor      Position
+	IsSelecting bool
+	IsMarkSet   bool
+
+	// The viewport's column and row offsets, in display columns and rows. While
+	// soft wrapping, the viewport starts at a row of the line at OffsetY.
+	OffsetX   int
+	OffsetY   int
+	OffsetRow int
 }
 
 // Create creates a new buffer for a given path.
-func Create(config *config.Config, path string) (Buffer, error) {
-	b := Buffer{
+func Create(config *config.Config, path string) (*Buffer, error) {
+	b := &Buffer{
 		Config:   config,
 		Path:     path,
 		FileType: support.GuessFileType(path),
-		CursorY:  1,
+		Syntax:   syntax.ForFile(path),
+		Format:   DefaultFormat(),
+		View:     &View{CursorY: 1},
 	}
 
-	// Attempt to open the file at the given path.
-	f, err := os.Open(path)
+	// Attempt to read the file at the given path. Files which do not exist yet
+	// start out as a single empty line.
+	data, err := ioutil.ReadFile(path)
 	if err != nil && !os.IsNotExist(err) {
-		return Buffer{}, fmt.Errorf("%v (%v)", path, err)
+		return nil, fmt.Errorf("%v (%v)", path, err)
 	}
 
-	// Read the file line by line and append each line to end of the buffer.
-	s := bufio.NewScanner(f)
-	for s.Scan() {
-		b.InsertLine(b.Length(), s.Text())
+	lines := []string{""}
+	if err == nil {
+		lines, b.Format = parseText(data)
+		b.stamp = makeFileStamp(path, data)
 	}
 
-	// If the file is completely empty, add an empty line to the buffer.
-	if b.Length() == 0 {
-		b.InsertLine(0, "")
+	// Append each line to the end of the buffer.
+	for _, l := range lines {
+		b.InsertLine(b.Length(), l)
 	}
 
-	f.Close()
+	// Loading the file should not be undoable.
+	b.clearHistory()
+
+	// Look for unsaved edits left behind by an earlier session.
+	b.Swap = findSwap(path)
 
 	return b, nil
 }
 
 // FromStrings creates a buffer from an array of strings rather than a file.
-func FromStrings(config *config.Config, name string, rawLines []string) Buffer {
-	b := Buffer{
+func FromStrings(config *config.Config, name string, rawLines []string) *Buffer {
+	b := &Buffer{
 		Config:   config,
 		Path:     name,
 		FileType: support.GuessFileType(name),
-		CursorY:  1,
+		Syntax:   syntax.ForFile(name),
+		Format:   DefaultFormat(),
+		View:     &View{CursorY: 1},
 	}
 
 	// Insert each array element as a new line.
@@ -104,6 +137,8 @@ func FromStrings(config *config.Config, name string, rawLines []string) Buffer {
 		b.InsertLine(0, "")
 	}
 
+	b.clearHistory()
+
 	return b
 }
 
@@ -112,6 +147,11 @@ func (b *Buffer) Length() int {
 	return len(b.Lines)
 }
 
+// Strings returns the text of each of the buffer's lines.
+func (b *Buffer) Strings() []string {
+	return b.lineTexts(0, b.Length())
+}
+
 // FileName extracts the name of the file from the buffer's file path.
 func (b *Buffer) FileName() string {
 	_, name := filepath.Split(b.Path)
@@ -134,21 +174,41 @@ func (b *Buffer) PreviousLine() *Line {
 	return &b.Lines[i]
 }
 
-func (b *Buffer) Write(path string) error {
-	var text string
+// setPath changes the buffer's path, updating its type and syntax to match. If
+// the syntax changes, every line is highlighted again.
+func (b *Buffer) setPath(path string) {
+	b.Path = path
+	b.FileType = support.GuessFileType(path)
 
-	// Append each line of the buffer (plus a newline) to the string.
-	for i := 0; i < b.Length(); i++ {
-		text += b.Lines[i].Text + "\n"
+	if s := syntax.ForFile(path); s != b.Syntax {
+		b.Syntax = s
+		b.Rehighlight()
 	}
+}
+
+// TypeName returns the name of the buffer's file type, falling back to the name
+// of its syntax if the type is not known.
+func (b *Buffer) TypeName() string {
+	if b.FileType == support.FileTypeUnknown && b.Syntax != nil {
+		return b.Syntax.Name
+	}
+
+	return string(b.FileType)
+}
+
+// Write writes the buffer's contents to the file at the given path, using the
+// buffer's format. The file is replaced atomically, keeping its permissions.
+func (b *Buffer) Write(path string) error {
+	text := formatText(b.lineTexts(0, b.Length()), b.Format)
 
-	err := ioutil.WriteFile(path, []byte(text), os.ModePerm)
+	err := b.writeFile(path, text)
 	if err != nil {
 		return err
 	} else {
-		b.Path = path
-		b.FileType = support.GuessFileType(path)
-		b.IsDirty = false
+		b.RemoveSwap()
+		b.setPath(path)
+		b.markSaved()
+		b.stamp = makeFileStamp(path, text)
 
 		return nil
 	}
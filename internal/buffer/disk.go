@@ -0,0 +1,102 @@
+package buffer
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// fileStamp identifies the version of a file a buffer was last loaded from or
+// saved to, so that changes made to the file by other programs are noticed.
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+// makeFileStamp returns the stamp for the contents of the file at the given
+// path.
+func makeFileStamp(path string, data []byte) fileStamp {
+	stamp := fileStamp{exists: true, size: int64(len(data)), hash: sha256.Sum256(data)}
+	if info, err := os.Stat(path); err == nil {
+		stamp.modTime = info.ModTime()
+	}
+
+	return stamp
+}
+
+// HasChangedOnDisk tells whether the buffer's file was changed by another
+// program since the buffer was loaded or saved. Files whose modification time
+// changed without their contents changing, or which were deleted, are not
+// considered changed.
+func (b *Buffer) HasChangedOnDisk() bool {
+	if !b.stamp.exists {
+		return false
+	}
+
+	info, err := os.Stat(b.Path)
+	if err != nil {
+		return false
+	} else if info.ModTime().Equal(b.stamp.modTime) && info.Size() == b.stamp.size {
+		return false
+	}
+
+	data, err := ioutil.ReadFile(b.Path)
+	if err != nil {
+		return false
+	}
+
+	if sha256.Sum256(data) == b.stamp.hash {
+		b.stamp.modTime = info.ModTime()
+		return false
+	}
+
+	return true
+}
+
+// IgnoreDiskChanges forgets about changes made to the buffer's file by other
+// programs, so that the user is only told about later changes.
+func (b *Buffer) IgnoreDiskChanges() {
+	if data, err := ioutil.ReadFile(b.Path); err == nil {
+		b.stamp = makeFileStamp(b.Path, data)
+	}
+}
+
+// DiskStrings returns the text of each line of the buffer's file.
+func (b *Buffer) DiskStrings() ([]string, error) {
+	data, err := ioutil.ReadFile(b.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, _ := parseText(data)
+	return lines, nil
+}
+
+// Reload replaces the buffer's contents with those of its file, discarding any
+// unsaved changes. The reload can be undone like any other edit.
+func (b *Buffer) Reload() error {
+	data, err := ioutil.ReadFile(b.Path)
+	if err != nil {
+		return err
+	}
+
+	lines, format := parseText(data)
+	cursor := b.cursor()
+
+	if text := strings.Join(lines, "\n"); text != strings.Join(b.Strings(), "\n") {
+		last := b.Length() - 1
+		b.Replace(Position{X: 0, Y: 1}, Position{X: b.Lines[last].Length(), Y: last + 1}, text)
+		b.SetCursor(cursor)
+	}
+
+	b.ClearSelection()
+	b.Format = format
+	b.stamp = makeFileStamp(b.Path, data)
+	b.markSaved()
+
+	return nil
+}
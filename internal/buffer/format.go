@@ -0,0 +1,133 @@
+package buffer
+
+import (
+	"bytes"
+	"strings"
+)
+
+// LineEnding is the sequence used to end each line of a file.
+type LineEnding int
+
+const (
+
+	// LineEndingLF ends lines with a line feed, as is usual on Unix.
+	LineEndingLF LineEnding = iota
+
+	// LineEndingCRLF ends lines with a carriage return and a line feed, as is
+	// usual on Windows.
+	LineEndingCRLF
+)
+
+// String returns the name of the line ending.
+func (l LineEnding) String() string {
+	if l == LineEndingCRLF {
+		return "CRLF"
+	}
+
+	return "LF"
+}
+
+// Sequence returns the characters which end a line.
+func (l LineEnding) Sequence() string {
+	if l == LineEndingCRLF {
+		return "\r\n"
+	}
+
+	return "\n"
+}
+
+// byteOrderMark is the UTF-8 encoding of the byte order mark.
+const byteOrderMark = "\xef\xbb\xbf"
+
+// Format describes how the text of a buffer is stored in its file, so that it
+// can be written back the way it was read.
+type Format struct {
+	LineEnding      LineEnding
+	HasBOM          bool
+	HasFinalNewline bool
+}
+
+// DefaultFormat returns the format used for new files.
+func DefaultFormat() Format {
+	return Format{LineEnding: LineEndingLF, HasFinalNewline: true}
+}
+
+// String describes the format for the status bar.
+func (f Format) String() string {
+	if f.HasBOM {
+		return f.LineEnding.String() + " (BOM)"
+	}
+
+	return f.LineEnding.String()
+}
+
+// parseText splits the contents of a file into lines and detects its format.
+// Files with mixed line endings use whichever ending is more common, and the
+// carriage returns of CRLF files are only removed at the end of lines.
+func parseText(data []byte) ([]string, Format) {
+	var f Format
+
+	if bytes.HasPrefix(data, []byte(byteOrderMark)) {
+		f.HasBOM = true
+		data = data[len(byteOrderMark):]
+	}
+
+	crlf := bytes.Count(data, []byte("\r\n"))
+	if crlf > bytes.Count(data, []byte("\n"))-crlf {
+		f.LineEnding = LineEndingCRLF
+	}
+
+	text := string(data)
+	if strings.HasSuffix(text, "\n") {
+		f.HasFinalNewline = true
+		text = text[:len(text)-1]
+	}
+
+	lines := strings.Split(text, "\n")
+	if f.LineEnding == LineEndingCRLF {
+		for i, line := range lines {
+			lines[i] = strings.TrimSuffix(line, "\r")
+		}
+	}
+
+	return lines, f
+}
+
+// formatText joins lines back into the contents of a file with the format.
+func formatText(lines []string, f Format) []byte {
+	var buf bytes.Buffer
+
+	if f.HasBOM {
+		buf.WriteString(byteOrderMark)
+	}
+
+	for i, line := range lines {
+		buf.WriteString(line)
+		if i < len(lines)-1 || f.HasFinalNewline {
+			buf.WriteString(f.LineEnding.Sequence())
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// SetLineEnding changes the line ending used when the buffer is written. The
+// buffer is considered modified until it is saved again.
+func (b *Buffer) SetLineEnding(l LineEnding) {
+	if b.Format.LineEnding == l || b.IsReadOnly {
+		return
+	}
+
+	f := b.Format
+	f.LineEnding = l
+	b.setFormat(f)
+}
+
+// setFormat changes the buffer's format and marks the buffer as modified.
+func (b *Buffer) setFormat(f Format) {
+	b.Format = f
+
+	// The saved state can no longer be reached by undoing.
+	b.History.savedIndex = -1
+	b.updateDirty()
+}
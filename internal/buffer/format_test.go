@@ -0,0 +1,142 @@
+package buffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		lines  []string
+		format Format
+	}{
+		{
+			name:   "empty",
+			data:   "",
+			lines:  []string{""},
+			format: Format{LineEnding: LineEndingLF},
+		},
+		{
+			name:   "lf",
+			data:   "a\nb\n",
+			lines:  []string{"a", "b"},
+			format: Format{LineEnding: LineEndingLF, HasFinalNewline: true},
+		},
+		{
+			name:   "lf without final newline",
+			data:   "a\nb",
+			lines:  []string{"a", "b"},
+			format: Format{LineEnding: LineEndingLF},
+		},
+		{
+			name:   "crlf",
+			data:   "a\r\nb\r\n",
+			lines:  []string{"a", "b"},
+			format: Format{LineEnding: LineEndingCRLF, HasFinalNewline: true},
+		},
+		{
+			name:   "crlf without final newline",
+			data:   "a\r\nb",
+			lines:  []string{"a", "b"},
+			format: Format{LineEnding: LineEndingCRLF},
+		},
+		{
+			name:   "blank lines at the end",
+			data:   "a\n\n",
+			lines:  []string{"a", ""},
+			format: Format{LineEnding: LineEndingLF, HasFinalNewline: true},
+		},
+		{
+			name:   "mostly lf",
+			data:   "a\r\nb\nc\n",
+			lines:  []string{"a\r", "b", "c"},
+			format: Format{LineEnding: LineEndingLF, HasFinalNewline: true},
+		},
+		{
+			name:   "mostly crlf",
+			data:   "a\r\nb\nc\r\n",
+			lines:  []string{"a", "b", "c"},
+			format: Format{LineEnding: LineEndingCRLF, HasFinalNewline: true},
+		},
+		{
+			name:   "carriage return inside a crlf line",
+			data:   "a\rb\r\n",
+			lines:  []string{"a\rb"},
+			format: Format{LineEnding: LineEndingCRLF, HasFinalNewline: true},
+		},
+		{
+			name:   "bom",
+			data:   byteOrderMark + "a\n",
+			lines:  []string{"a"},
+			format: Format{LineEnding: LineEndingLF, HasBOM: true, HasFinalNewline: true},
+		},
+		{
+			name:   "bom only",
+			data:   byteOrderMark,
+			lines:  []string{""},
+			format: Format{LineEnding: LineEndingLF, HasBOM: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, format := parseText([]byte(tt.data))
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("lines = %q, want %q", lines, tt.lines)
+			}
+			if format != tt.format {
+				t.Errorf("format = %+v, want %+v", format, tt.format)
+			}
+		})
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []string
+		format Format
+		data   string
+	}{
+		{"lf", []string{"a", "b"}, Format{LineEnding: LineEndingLF, HasFinalNewline: true}, "a\nb\n"},
+		{"lf without final newline", []string{"a", "b"}, Format{LineEnding: LineEndingLF}, "a\nb"},
+		{"crlf", []string{"a", "b"}, Format{LineEnding: LineEndingCRLF, HasFinalNewline: true}, "a\r\nb\r\n"},
+		{"crlf without final newline", []string{"a", "b"}, Format{LineEnding: LineEndingCRLF}, "a\r\nb"},
+		{"bom", []string{"a"}, Format{HasBOM: true, HasFinalNewline: true}, byteOrderMark + "a\n"},
+		{"empty line", []string{""}, Format{HasFinalNewline: true}, "\n"},
+		{"empty line without final newline", []string{""}, Format{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(formatText(tt.lines, tt.format)); got != tt.data {
+				t.Errorf("formatText = %q, want %q", got, tt.data)
+			}
+		})
+	}
+}
+
+// TestFormatRoundTrip checks that files are written back exactly as they were
+// read, whatever their format.
+func TestFormatRoundTrip(t *testing.T) {
+	files := []string{
+		"",
+		"\n",
+		"a\nb\n",
+		"a\nb",
+		"a\r\nb\r\n",
+		"a\r\nb",
+		"\r\n\r\n",
+		byteOrderMark + "a\r\n",
+		byteOrderMark,
+	}
+
+	for _, data := range files {
+		lines, format := parseText([]byte(data))
+		if got := string(formatText(lines, format)); got != data {
+			t.Errorf("round trip of %q = %q", data, got)
+		}
+	}
+}
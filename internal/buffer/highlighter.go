@@ -1,19 +1,17 @@
 package buffer
 
 import (
+	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/jonpalmisc/atto/internal/syntax"
-	"github.com/nsf/termbox-go"
 )
 
+// isSeparator tells whether a rune separates words. Underscores and number
+// signs are considered part of words, the latter for C preprocessor keywords.
 func isSeparator(c rune) bool {
-	switch c {
-	case ' ', ',', '.', ';', '(', ')', '[', ']', '+', '-', '/', '*', '=', '%':
-		return true
-	default:
-		return false
-	}
+	return !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '#'
 }
 
 func fill(slice *[]TokenType, start, length int, fill TokenType) {
@@ -22,6 +20,73 @@ func fill(slice *[]TokenType, start, length int, fill TokenType) {
 	}
 }
 
+// hasPrefixAt tells whether a pattern occurs in a line at the given index. An
+// empty pattern never occurs.
+func hasPrefixAt(text []rune, i int, pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+
+	for _, r := range pattern {
+		if i >= len(text) || text[i] != r {
+			return false
+		}
+		i++
+	}
+
+	return true
+}
+
+// wordEnd returns the index at which the word starting at index i ends.
+func wordEnd(text []rune, i int) int {
+	for i < len(text) && !isSeparator(text[i]) {
+		i++
+	}
+
+	return i
+}
+
+// numberEnd returns the index at which the number starting at index i ends,
+// or -1 if the word starting there is not a valid number.
+func numberEnd(text []rune, i int, rules *syntax.Numbers) int {
+	j := i
+
+	// Hexadecimal numbers may contain letters, so they are handled separately.
+	isHex := rules.AllowHex && j+1 < len(text) && text[j] == '0' && (text[j+1] == 'x' || text[j+1] == 'X')
+	if isHex {
+		j += 2
+	}
+
+	seenDecimal := false
+	for ; j < len(text); j++ {
+		r := text[j]
+
+		switch {
+		case unicode.IsDigit(r), isHex && strings.ContainsRune("abcdefABCDEF", r):
+			continue
+		case strings.ContainsRune(rules.Separators, r):
+			continue
+		case r == '.' && !isHex && !seenDecimal && j+1 < len(text) && unicode.IsDigit(text[j+1]):
+			seenDecimal = true
+			continue
+		}
+
+		break
+	}
+
+	// Allow any suffixes at the end of the number.
+	for j < len(text) && strings.ContainsRune(rules.Suffixes, text[j]) {
+		j++
+	}
+
+	// The number must end at a separator, otherwise it is part of a word.
+	if j < len(text) && !isSeparator(text[j]) {
+		return -1
+	}
+
+	return j
+}
+
 // TokenType represents the type of token a rune belongs to.
 type TokenType int
 
@@ -41,107 +106,183 @@ const (
 
 	// TokenTypeComment represents a comment.
 	TokenTypeComment
+
+	// TokenTypeType represents a built-in type.
+	TokenTypeType
 )
 
-// Color returns the appropriate highlighting color for a highlight type.
-func (t TokenType) Color() termbox.Attribute {
-	switch t {
-	case TokenTypeKeyword:
-		return termbox.ColorMagenta
-	case TokenTypeNumber:
-		return termbox.ColorBlue
-	case TokenTypeString:
-		return termbox.ColorGreen
-	case TokenTypeComment:
-		return termbox.ColorCyan
-	default:
-		return termbox.ColorDefault
-	}
+// HighlightState is the state of the highlighter at the end of a line, which
+// carries over to the start of the next line.
+type HighlightState struct {
+
+	// Whether the line ends inside of a multi-line comment.
+	InComment bool
+
+	// The delimiter of the raw string the line ends inside of, if any.
+	RawStringDelimiter rune
 }
 
-// Highlight updates the character to highlighting mapping for a line.
-func (l *Line) Highlight(s *syntax.Syntax) {
+// Highlight updates the character to highlighting mapping for a line, starting
+// in the given state, and returns the state at the end of the line.
+func (l *Line) Highlight(s *syntax.Syntax, state HighlightState) HighlightState {
 	T := &l.TokenTypes
 
-	// Keep track of whether we are inside a string and whether the last rune
-	// was a separator.
-	insideString := false
+	// Keep track of the delimiter of the string we are inside of, if any, and
+	// whether the last rune was a separator.
+	var stringDelimiter rune
 	afterSeparator := true
 
-	// Get the display text for the line as an array of runes & the its length.
-	text := []rune(l.DisplayText)
+	// Get the line's runes and its length.
+	text := l.Runes
 	length := len(text)
 
+	mlcStart, mlcEnd := s.Patterns.MultiLineCommentStart, s.Patterns.MultiLineCommentEnd
+
 	for i := 0; i < length; i++ {
 		r := text[i]
 
-		// Get the type of the last token if accessible. or default to text.
-		lastTokenType := TokenTypeText
-		if i > 0 {
-			lastTokenType = (*T)[i-1]
+		// If we are inside of a multi-line comment, keep highlighting until we
+		// hit the end of the comment.
+		if state.InComment {
+			if hasPrefixAt(text, i, mlcEnd) {
+				n := utf8.RuneCountInString(mlcEnd)
+				fill(T, i, n, TokenTypeComment)
+
+				i += n - 1
+				state.InComment = false
+				afterSeparator = true
+			} else {
+				(*T)[i] = TokenTypeComment
+			}
+
+			continue
+		}
+
+		// If we are inside of a raw string, keep highlighting until we hit the
+		// delimiter which started it. Raw strings do not support escapes.
+		if state.RawStringDelimiter != 0 {
+			(*T)[i] = TokenTypeString
+
+			if r == state.RawStringDelimiter {
+				state.RawStringDelimiter = 0
+				afterSeparator = true
+			}
+
+			continue
 		}
 
 		// If we are already within a string, keep highlighting until we hit
-		// another quote character.
-		if insideString {
+		// the delimiter which started it, skipping over escaped runes.
+		if stringDelimiter != 0 {
 			(*T)[i] = TokenTypeString
 
-			insideString = r != '"'
+			if s.IsEscape(r) && i+1 < length {
+				(*T)[i+1] = TokenTypeString
+				i++
+			} else if r == stringDelimiter {
+				stringDelimiter = 0
+				afterSeparator = true
+			}
+
 			continue
 		}
 
 		// If we hit the beginning of a single line comment, highlight the rest
 		// of the line and break out of the loop.
-		scsPattern := &s.Patterns.SingleLineCommentStart
-		scsLength := len(*scsPattern)
-		if i+scsLength <= length && string(text[i:i+scsLength]) == *scsPattern {
+		if hasPrefixAt(text, i, s.Patterns.SingleLineCommentStart) {
 			fill(T, i, length-i, TokenTypeComment)
 			break
 		}
 
-		// If we hit a quotation mark, set insideString to true and highlight it.
-		if r == '"' || r == '\'' {
-			(*T)[i] = TokenTypeString
-			insideString = true
+		// If we hit the beginning of a multi-line comment, highlight it and
+		// remember that we are inside of a comment.
+		if mlcEnd != "" && hasPrefixAt(text, i, mlcStart) {
+			n := utf8.RuneCountInString(mlcStart)
+			fill(T, i, n, TokenTypeComment)
+
+			i += n - 1
+			state.InComment = true
 			continue
 		}
 
-		// If our character is a digit, is after a separator or trailing another
-		// digit, or is a decimal trailing a digit, highlight it as a number.
-		isDigit := unicode.IsDigit(r)
-		isAfterDigit := lastTokenType == TokenTypeNumber
-		isAfterDecimal := r == '.' && lastTokenType == TokenTypeNumber
-		if isDigit && (afterSeparator || isAfterDigit) || (isAfterDecimal) {
-			(*T)[i] = TokenTypeNumber
+		// If we hit a string delimiter, remember it and highlight it.
+		if s.IsRawStringDelimiter(r) {
+			(*T)[i] = TokenTypeString
+			state.RawStringDelimiter = r
+			continue
+		} else if s.IsStringDelimiter(r) {
+			(*T)[i] = TokenTypeString
+			stringDelimiter = r
 			continue
 		}
 
-		// If the current rune is after a separator, check if it is the start of
-		// a keyword.
-		if afterSeparator {
-			for _, keyword := range s.Keywords {
-				keywordLength := len(keyword)
+		// If the current rune is after a separator, check whether it starts a
+		// number, a keyword or a type.
+		if afterSeparator && !isSeparator(r) {
+			end := wordEnd(text, i)
 
-				// If testing the keyword will cause an index out of bounds
-				// error, just skip to the next keyword.
-				if i+keywordLength >= length {
-					continue
-				}
-
-				// If the character after the keyword is not a separator, it may
-				// be part of a compound word, and should not be highlighted.
-				if !isSeparator(text[i+keywordLength]) {
-					continue
-				}
-
-				// If we have made it this far and the slice we are inspecting
-				// matches the keyword, highlight it.
-				if string(text[i:i+keywordLength]) == keyword {
-					fill(T, i, keywordLength, TokenTypeKeyword)
+			if s.Numbers.Enabled && unicode.IsDigit(r) {
+				if numberEnd := numberEnd(text, i, &s.Numbers); numberEnd >= 0 {
+					end = numberEnd
+					fill(T, i, end-i, TokenTypeNumber)
 				}
+			} else if word := string(text[i:end]); s.IsKeyword(word) {
+				fill(T, i, end-i, TokenTypeKeyword)
+			} else if s.IsType(word) {
+				fill(T, i, end-i, TokenTypeType)
 			}
+
+			// Skip to the last rune of the word.
+			i = end - 1
+			afterSeparator = false
+			continue
 		}
 
 		afterSeparator = isSeparator(r)
 	}
+
+	return state
+}
+
+// refreshHighlighting highlights the line again, starting in the given state.
+func (l *Line) refreshHighlighting(state HighlightState) {
+	l.TokenTypes = make([]TokenType, len(l.Runes))
+	l.startState, l.isHighlighted = state, true
+
+	if l.Buffer.Config.UseHighlighting && l.Buffer.Syntax != nil {
+		l.endState = l.Highlight(l.Buffer.Syntax, state)
+	} else {
+		l.endState = HighlightState{}
+	}
+}
+
+// highlightFrom refreshes the highlighting of the lines starting at index y.
+// Since the highlighter's state carries over from line to line, this continues
+// until a line is reached which is already highlighted and starts in the same
+// state as before, as the lines after it cannot have been affected.
+func (b *Buffer) highlightFrom(y int) {
+	for i := y; i < b.Length(); i++ {
+		line := &b.Lines[i]
+
+		state := HighlightState{}
+		if i > 0 {
+			state = b.Lines[i-1].endState
+		}
+
+		if line.isHighlighted && line.startState == state {
+			return
+		}
+
+		line.refreshHighlighting(state)
+	}
+}
+
+// Rehighlight highlights every line of the buffer again, such as after its
+// syntax or the highlighting setting changes.
+func (b *Buffer) Rehighlight() {
+	for i := range b.Lines {
+		b.Lines[i].isHighlighted = false
+	}
+
+	b.highlightFrom(0)
 }
@@ -0,0 +1,329 @@
+package buffer
+
+// Position represents a location in a buffer. Like the buffer's cursor, the Y
+// value is one-based, while the X value is a zero-based index into the line.
+type Position struct {
+	X int
+	Y int
+}
+
+// EditKind is the kind of an edit made to a buffer, used to decide whether
+// consecutive edits can be grouped together into a single undo step.
+type EditKind int
+
+const (
+
+	// EditKindOther represents an edit which is never grouped with others.
+	EditKindOther EditKind = 0
+
+	// EditKindInsert represents the insertion of a single rune while typing.
+	EditKindInsert EditKind = 1
+
+	// EditKindDelete represents the deletion of a single rune while typing.
+	EditKindDelete EditKind = 2
+)
+
+// change describes the replacement of a range of lines in the buffer. The
+// lines starting at index Y are replaced with the contents of After; undoing
+// the change puts the contents of Before back in their place.
+type change struct {
+	Y      int
+	Before []string
+	After  []string
+}
+
+// Edit is a single undoable step, made up of one or more changes.
+type Edit struct {
+	Kind    EditKind
+	changes []change
+
+	// The cursor's position before and after the edit was made.
+	CursorBefore Position
+	CursorAfter  Position
+}
+
+// History is the edit journal of a buffer.
+type History struct {
+	undo []Edit
+	redo []Edit
+
+	// The number of undo steps at the time the buffer was last saved, or -1 if
+	// the saved state can no longer be reached.
+	savedIndex int
+
+	// The group currently being recorded and how deeply groups are nested.
+	group      *Edit
+	groupDepth int
+
+	// The change currently being recorded and how deeply edits are nested.
+	// Nested edits are covered by the outermost one and are not recorded.
+	editDepth  int
+	editKind   EditKind
+	editY      int
+	editBefore []string
+	editCursor Position
+}
+
+// cursor returns the cursor's current position.
+func (v *View) cursor() Position {
+	return Position{X: v.CursorX, Y: v.CursorY}
+}
+
+// clamp returns the position inside of the buffer which is closest to p.
+func (b *Buffer) clamp(p Position) Position {
+	if p.Y < 1 {
+		p.Y = 1
+	} else if p.Y > b.Length() {
+		p.Y = b.Length()
+	}
+
+	if length := b.Lines[p.Y-1].Length(); p.X > length {
+		p.X = length
+	}
+
+	return p
+}
+
+// SetCursor moves the cursor to the given position, keeping it in bounds.
+func (b *Buffer) SetCursor(p Position) {
+	p = b.clamp(p)
+	b.CursorX, b.CursorY = p.X, p.Y
+}
+
+// ClampView keeps the cursor and the selection's anchor of a view of the buffer
+// inside of the buffer, such as after the buffer was edited through another
+// view which removed lines from it.
+func (b *Buffer) ClampView(v *View) {
+	cursor := b.clamp(v.cursor())
+	v.CursorX, v.CursorY = cursor.X, cursor.Y
+
+	if v.Anchor.Y < 1 {
+		v.Anchor = Position{X: 0, Y: 1}
+	} else if v.Anchor.Y > b.Length() {
+		v.Anchor = Position{X: b.Lines[b.Length()-1].Length(), Y: b.Length()}
+	} else if length := b.Lines[v.Anchor.Y-1].Length(); v.Anchor.X > length {
+		v.Anchor.X = length
+	}
+}
+
+// lineTexts returns a copy of the text of count lines starting at index y.
+func (b *Buffer) lineTexts(y, count int) []string {
+	texts := make([]string, 0, count)
+	for i := y; i < y+count && i < b.Length(); i++ {
+		texts = append(texts, b.Lines[i].Text)
+	}
+
+	return texts
+}
+
+// replaceLines replaces count lines starting at index y with new lines made
+// from the given texts. The lines after them are only moved if the number of
+// lines changes, so that replacing lines one at a time stays cheap.
+func (b *Buffer) replaceLines(y, count int, texts []string) {
+	n, length := len(texts), b.Length()
+
+	// https://github.com/golang/go/wiki/SliceTricks
+	if n > count {
+		b.Lines = append(b.Lines, make([]Line, n-count)...)
+		copy(b.Lines[y+n:], b.Lines[y+count:length])
+	} else if n < count {
+		copy(b.Lines[y+n:], b.Lines[y+count:])
+		for i := length - count + n; i < length; i++ {
+			b.Lines[i] = Line{}
+		}
+		b.Lines = b.Lines[:length-count+n]
+	}
+
+	for i, text := range texts {
+		b.Lines[y+i] = MakeBufferLine(b, text)
+	}
+}
+
+// beginEdit starts recording a change to count lines starting at index y.
+func (b *Buffer) beginEdit(kind EditKind, y, count int) {
+	h := &b.History
+
+	h.editDepth++
+	if h.editDepth > 1 {
+		return
+	}
+
+	h.editKind = kind
+	h.editY = y
+	h.editBefore = b.lineTexts(y, count)
+	h.editCursor = b.cursor()
+}
+
+// endEdit finishes recording a change started with beginEdit, which left count
+// lines in place of the ones it was started with.
+func (b *Buffer) endEdit(count int) {
+	h := &b.History
+
+	h.editDepth--
+	if h.editDepth > 0 {
+		return
+	}
+
+	b.highlightFrom(h.editY)
+
+	c := change{
+		Y:      h.editY,
+		Before: h.editBefore,
+		After:  b.lineTexts(h.editY, count),
+	}
+
+	// If a group is being recorded, the change becomes part of it instead of
+	// being an undo step of its own.
+	if h.groupDepth > 0 {
+		h.group.changes = append(h.group.changes, c)
+		return
+	}
+
+	b.commitEdit(Edit{
+		Kind:         h.editKind,
+		changes:      []change{c},
+		CursorBefore: h.editCursor,
+		CursorAfter:  b.cursor(),
+	})
+}
+
+// BeginGroup starts recording a group of edits which will be undone and redone
+// as a single step. Every call must be paired with a call to EndGroup.
+func (b *Buffer) BeginGroup() {
+	h := &b.History
+
+	h.groupDepth++
+	if h.groupDepth == 1 {
+		h.group = &Edit{Kind: EditKindOther, CursorBefore: b.cursor()}
+	}
+}
+
+// EndGroup finishes recording a group of edits started with BeginGroup.
+func (b *Buffer) EndGroup() {
+	h := &b.History
+
+	h.groupDepth--
+	if h.groupDepth > 0 {
+		return
+	}
+
+	group := h.group
+	h.group = nil
+
+	if len(group.changes) > 0 {
+		group.CursorAfter = b.cursor()
+		b.commitEdit(*group)
+	}
+}
+
+// canMerge tells whether an edit can be merged into the previous one. Only
+// consecutive insertions or deletions on the same line are merged.
+func canMerge(last, edit *Edit) bool {
+	if edit.Kind == EditKindOther || last.Kind != edit.Kind {
+		return false
+	}
+
+	if last.CursorAfter != edit.CursorBefore {
+		return false
+	}
+
+	if len(last.changes) != 1 || len(edit.changes) != 1 {
+		return false
+	}
+
+	lc, ec := &last.changes[0], &edit.changes[0]
+	return lc.Y == ec.Y && len(lc.After) == 1 && len(ec.Before) == 1 && len(ec.After) == 1
+}
+
+// commitEdit pushes a finished edit onto the undo stack.
+func (b *Buffer) commitEdit(edit Edit) {
+	h := &b.History
+
+	// Making a new edit discards everything which could have been redone. If
+	// the saved state was among those edits, it can no longer be reached.
+	if h.savedIndex > len(h.undo) {
+		h.savedIndex = -1
+	}
+	h.redo = nil
+
+	// Merge the edit into the previous one if possible, unless the previous one
+	// marks the saved state.
+	n := len(h.undo)
+	if n > 0 && n != h.savedIndex && canMerge(&h.undo[n-1], &edit) {
+		last := &h.undo[n-1]
+		last.changes[0].After = edit.changes[0].After
+		last.CursorAfter = edit.CursorAfter
+	} else {
+		h.undo = append(h.undo, edit)
+	}
+
+	b.updateDirty()
+}
+
+// updateDirty recalculates whether the buffer differs from its saved state.
+func (b *Buffer) updateDirty() {
+	b.IsDirty = len(b.History.undo) != b.History.savedIndex
+	b.isSwapStale = true
+}
+
+// markSaved records the current state of the buffer as its saved state.
+func (b *Buffer) markSaved() {
+	b.History.savedIndex = len(b.History.undo)
+	b.updateDirty()
+}
+
+// clearHistory discards the buffer's entire history and marks it as saved.
+func (b *Buffer) clearHistory() {
+	b.History = History{}
+	b.updateDirty()
+}
+
+// Undo reverts the most recent edit. It returns false if there is nothing to
+// undo.
+func (b *Buffer) Undo() bool {
+	h := &b.History
+	if b.IsReadOnly || len(h.undo) == 0 {
+		return false
+	}
+
+	edit := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+
+	// Changes must be reverted in the opposite order they were made in.
+	for i := len(edit.changes) - 1; i >= 0; i-- {
+		c := edit.changes[i]
+		b.replaceLines(c.Y, len(c.After), c.Before)
+		b.highlightFrom(c.Y)
+	}
+
+	h.redo = append(h.redo, edit)
+	b.SetCursor(edit.CursorBefore)
+	b.ClearSelection()
+	b.updateDirty()
+
+	return true
+}
+
+// Redo reapplies the most recently undone edit. It returns false if there is
+// nothing to redo.
+func (b *Buffer) Redo() bool {
+	h := &b.History
+	if b.IsReadOnly || len(h.redo) == 0 {
+		return false
+	}
+
+	edit := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+
+	for _, c := range edit.changes {
+		b.replaceLines(c.Y, len(c.Before), c.After)
+		b.highlightFrom(c.Y)
+	}
+
+	h.undo = append(h.undo, edit)
+	b.SetCursor(edit.CursorAfter)
+	b.ClearSelection()
+	b.updateDirty()
+
+	return true
+}
@@ -0,0 +1,254 @@
+package buffer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonpalmisc/atto/internal/config"
+)
+
+// newTestBuffer creates a buffer with the given lines and the default
+// configuration, with its cursor at the start of the buffer.
+func newTestBuffer(lines ...string) *Buffer {
+	c := config.Default()
+	return FromStrings(&c, "test.txt", lines)
+}
+
+// typeText inserts each rune of a string at the cursor.
+func typeText(b *Buffer, s string) {
+	for _, c := range s {
+		b.InsertRune(c)
+	}
+}
+
+func TestHistoryGrouping(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		edit  func(b *Buffer)
+		after []string
+		steps int
+	}{
+		{
+			name:  "typing is one step",
+			lines: []string{""},
+			edit:  func(b *Buffer) { typeText(b, "abc") },
+			after: []string{"abc"},
+			steps: 1,
+		},
+		{
+			name:  "deleting is one step",
+			lines: []string{"abc"},
+			edit: func(b *Buffer) {
+				b.SetCursor(Position{X: 3, Y: 1})
+				b.DeleteRune()
+				b.DeleteRune()
+			},
+			after: []string{"a"},
+			steps: 1,
+		},
+		{
+			name:  "typing after deleting is a new step",
+			lines: []string{""},
+			edit: func(b *Buffer) {
+				typeText(b, "ab")
+				b.DeleteRune()
+				typeText(b, "c")
+			},
+			after: []string{"ac"},
+			steps: 3,
+		},
+		{
+			name:  "moving the cursor starts a new step",
+			lines: []string{""},
+			edit: func(b *Buffer) {
+				typeText(b, "ab")
+				b.SetCursor(Position{X: 0, Y: 1})
+				typeText(b, "c")
+			},
+			after: []string{"cab"},
+			steps: 2,
+		},
+		{
+			name:  "breaking a line is a step of its own",
+			lines: []string{""},
+			edit: func(b *Buffer) {
+				typeText(b, "a")
+				b.BreakLine()
+				typeText(b, "b")
+			},
+			after: []string{"a", "b"},
+			steps: 3,
+		},
+		{
+			name:  "joining lines is a step of its own",
+			lines: []string{"a", "b"},
+			edit: func(b *Buffer) {
+				b.SetCursor(Position{X: 0, Y: 2})
+				b.DeleteRune()
+			},
+			after: []string{"ab"},
+			steps: 1,
+		},
+		{
+			name:  "groups are one step",
+			lines: []string{"a", "b", "c"},
+			edit: func(b *Buffer) {
+				b.BeginGroup()
+				b.RemoveLine(1)
+				b.InsertLine(0, "d")
+				typeText(b, "e")
+				b.EndGroup()
+			},
+			after: []string{"ed", "a", "c"},
+			steps: 1,
+		},
+		{
+			name:  "nested groups are one step",
+			lines: []string{"a"},
+			edit: func(b *Buffer) {
+				b.BeginGroup()
+				b.InsertLine(1, "b")
+				b.BeginGroup()
+				b.InsertLine(2, "c")
+				b.EndGroup()
+				b.InsertLine(3, "d")
+				b.EndGroup()
+			},
+			after: []string{"a", "b", "c", "d"},
+			steps: 1,
+		},
+		{
+			name:  "empty groups are not recorded",
+			lines: []string{"a"},
+			edit: func(b *Buffer) {
+				b.BeginGroup()
+				b.EndGroup()
+			},
+			after: []string{"a"},
+			steps: 0,
+		},
+		{
+			name:  "typing is not merged across a save",
+			lines: []string{""},
+			edit: func(b *Buffer) {
+				typeText(b, "a")
+				b.markSaved()
+				typeText(b, "b")
+			},
+			after: []string{"ab"},
+			steps: 2,
+		},
+		{
+			name:  "replacing is one step",
+			lines: []string{"one", "two", "three"},
+			edit: func(b *Buffer) {
+				b.Replace(Position{X: 1, Y: 1}, Position{X: 2, Y: 3}, "x\ny")
+			},
+			after: []string{"ox", "yree"},
+			steps: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBuffer(tt.lines...)
+			tt.edit(b)
+			if got := b.Strings(); !reflect.DeepEqual(got, tt.after) {
+				t.Fatalf("after editing, lines = %q, want %q", got, tt.after)
+			}
+
+			undos := 0
+			for b.Undo() {
+				undos++
+			}
+			if undos != tt.steps {
+				t.Errorf("undid %v steps, want %v", undos, tt.steps)
+			}
+			if got := b.Strings(); !reflect.DeepEqual(got, tt.lines) {
+				t.Errorf("after undoing, lines = %q, want %q", got, tt.lines)
+			}
+
+			redos := 0
+			for b.Redo() {
+				redos++
+			}
+			if redos != tt.steps {
+				t.Errorf("redid %v steps, want %v", redos, tt.steps)
+			}
+			if got := b.Strings(); !reflect.DeepEqual(got, tt.after) {
+				t.Errorf("after redoing, lines = %q, want %q", got, tt.after)
+			}
+		})
+	}
+}
+
+func TestHistoryCursor(t *testing.T) {
+	b := newTestBuffer("abc", "def")
+	b.SetCursor(Position{X: 1, Y: 2})
+	typeText(b, "xy")
+
+	if !b.Undo() {
+		t.Fatal("nothing to undo")
+	}
+	if got, want := b.cursor(), (Position{X: 1, Y: 2}); got != want {
+		t.Errorf("cursor after undo = %v, want %v", got, want)
+	}
+
+	if !b.Redo() {
+		t.Fatal("nothing to redo")
+	}
+	if got, want := b.cursor(), (Position{X: 3, Y: 2}); got != want {
+		t.Errorf("cursor after redo = %v, want %v", got, want)
+	}
+}
+
+func TestHistoryDirty(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(b *Buffer)
+		dirty bool
+	}{
+		{"unedited", func(b *Buffer) {}, false},
+		{"edited", func(b *Buffer) { typeText(b, "a") }, true},
+		{"undone", func(b *Buffer) { typeText(b, "a"); b.Undo() }, false},
+		{"redone", func(b *Buffer) { typeText(b, "a"); b.Undo(); b.Redo() }, true},
+		{"saved", func(b *Buffer) { typeText(b, "a"); b.markSaved() }, false},
+		{"undone past a save", func(b *Buffer) { typeText(b, "a"); b.markSaved(); b.Undo() }, true},
+		{"redone to a save", func(b *Buffer) { typeText(b, "a"); b.markSaved(); b.Undo(); b.Redo() }, false},
+		{
+			name: "save no longer reachable",
+			edit: func(b *Buffer) {
+				typeText(b, "a")
+				b.markSaved()
+				b.Undo()
+				typeText(b, "b")
+				b.Undo()
+			},
+			dirty: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBuffer("")
+			tt.edit(b)
+			if b.IsDirty != tt.dirty {
+				t.Errorf("IsDirty = %v, want %v", b.IsDirty, tt.dirty)
+			}
+		})
+	}
+}
+
+func TestHistoryReadOnly(t *testing.T) {
+	b := newTestBuffer("")
+	typeText(b, "a")
+	b.IsReadOnly = true
+
+	if b.Undo() {
+		t.Error("undid an edit of a read-only buffer")
+	}
+	if got := b.Strings(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("lines = %q, want %q", got, []string{"a"})
+	}
+}
@@ -0,0 +1,176 @@
+package buffer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// indentUnit returns the text of a single level of indentation, which is a tab,
+// or a tab's width of spaces when using soft tabs.
+func (b *Buffer) indentUnit() string {
+	if b.Config.UseSoftTabs {
+		return strings.Repeat(" ", b.Config.TabSize)
+	}
+
+	return "\t"
+}
+
+// indentText returns the indentation which is the given number of columns wide,
+// made up of tabs, or of spaces when using soft tabs.
+func (b *Buffer) indentText(width int) string {
+	if width < 0 {
+		width = 0
+	}
+
+	if b.Config.UseSoftTabs {
+		return strings.Repeat(" ", width)
+	}
+
+	tabSize := b.Config.TabSize
+	return strings.Repeat("\t", width/tabSize) + strings.Repeat(" ", width%tabSize)
+}
+
+// indentWidth returns the width of the line's indentation in columns.
+func (l *Line) indentWidth() int {
+	return l.AdjustedX(l.IndentLength())
+}
+
+// setIndent replaces the indentation of the line at index i with one the given
+// number of columns wide. The highlighting of the rest of the line is kept, and
+// the cursor stays on the same rune if it is on the line.
+func (b *Buffer) setIndent(i, width int) {
+	line := &b.Lines[i]
+	length := line.IndentLength()
+	indent := b.indentText(width)
+
+	if line.Slice(0, length) == indent {
+		return
+	}
+
+	types := line.TokenTypes[length:]
+	line.Text = indent + line.Slice(length, line.Length())
+	line.Update()
+	copy(line.TokenTypes[len(indent):], types)
+
+	if b.CursorY-1 == i {
+		x := b.CursorX - length
+		if x < 0 {
+			x = 0
+		}
+
+		b.CursorX = len(indent) + x
+	}
+}
+
+// codeEnd returns the index just past the last rune of code in the line before
+// index x, not counting trailing whitespace, strings and comments.
+func (l *Line) codeEnd(x int) int {
+	for x > 0 && (unicode.IsSpace(l.Runes[x-1]) || !l.isCode(x-1)) {
+		x--
+	}
+
+	return x
+}
+
+// opensIndent tells whether the code of a line before index x ends with one of
+// the syntax's openers.
+func (b *Buffer) opensIndent(l *Line, x int) bool {
+	if b.Syntax == nil {
+		return false
+	}
+
+	head := l.Slice(0, l.codeEnd(x))
+	for _, o := range b.Syntax.Indent.Openers {
+		if o != "" && strings.HasSuffix(head, o) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// closesIndent tells whether a line starts with one of the syntax's closers.
+func (b *Buffer) closesIndent(l *Line) bool {
+	x := l.IndentLength()
+	return b.Syntax != nil && x < l.Length() && b.Syntax.IsIndentCloser(l.Runes[x]) && l.isCode(x)
+}
+
+// indentAfter returns the width in columns of the indentation of a line typed
+// after the text before index x of the line at index i.
+func (b *Buffer) indentAfter(i, x int) int {
+	line := &b.Lines[i]
+	width := line.indentWidth()
+
+	// A line ending with a bracket which pairs with one on an earlier line,
+	// such as the last line of a call spread over several lines, is followed by
+	// the indentation of the line the bracket was opened on.
+	if end := line.codeEnd(x) - 1; end >= 0 {
+		if _, _, opens, ok := line.bracketAt(end); ok && !opens {
+			if p, ok := b.matchBracket(Position{X: end, Y: i + 1}); ok && p.Y-1 < i {
+				width = b.Lines[p.Y-1].indentWidth()
+			}
+		}
+	}
+
+	if b.opensIndent(line, x) {
+		width += b.Config.TabSize
+	}
+
+	return width
+}
+
+// dedent takes away a level of indentation from the line the cursor is on,
+// after a closer was typed at its start. If the closer is a bracket, the line
+// is indented as far as the line with the bracket it pairs with instead.
+func (b *Buffer) dedent() {
+	line := b.FocusedLine()
+	width := line.indentWidth() - b.Config.TabSize
+
+	if p, ok := b.matchBracket(Position{X: b.CursorX - 1, Y: b.CursorY}); ok {
+		width = b.Lines[p.Y-1].indentWidth()
+	}
+
+	b.setIndent(b.CursorY-1, width)
+}
+
+// Reindent indents the lines from first to last again, as if they had been
+// typed one after another. Blank lines are emptied, and lines which start
+// inside of a raw string are left as they are.
+func (b *Buffer) Reindent(first, last int) {
+	if b.IsReadOnly || b.Syntax == nil {
+		return
+	}
+
+	// The indentation carries on from the last line before the range which is
+	// not blank.
+	width := 0
+	for i := first - 2; i >= 0; i-- {
+		if !b.isBlank(i) {
+			width = b.indentAfter(i, b.Lines[i].Length())
+			break
+		}
+	}
+
+	b.beginEdit(EditKindOther, first-1, last-first+1)
+
+	for i := first - 1; i < last; i++ {
+		line := &b.Lines[i]
+
+		if line.startState.RawStringDelimiter != 0 {
+			continue
+		} else if b.isBlank(i) {
+			b.setIndent(i, 0)
+			continue
+		}
+
+		if b.closesIndent(line) {
+			b.setIndent(i, width-b.Config.TabSize)
+		} else {
+			b.setIndent(i, width)
+		}
+
+		width = b.indentAfter(i, line.Length())
+	}
+
+	b.endEdit(last - first + 1)
+}
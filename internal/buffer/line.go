@@ -2,17 +2,24 @@ package buffer
 
 import (
 	"strings"
+	"unicode/utf8"
 
 	"github.com/jonpalmisc/atto/internal/support"
-	"github.com/jonpalmisc/atto/internal/syntax"
 )
 
-// Line represents a single line in a buffer.
+// Line represents a single line in a buffer. Positions within a line are rune
+// indices rather than byte indices into the line's text.
 type Line struct {
-	Buffer      *Buffer
-	Text        string
-	DisplayText string
-	TokenTypes  []TokenType
+	Buffer     *Buffer
+	Text       string
+	Runes      []rune
+	TokenTypes []TokenType
+
+	// The highlighter's state at the start and end of the line, and whether
+	// the line has been highlighted since its text last changed.
+	startState    HighlightState
+	endState      HighlightState
+	isHighlighted bool
 }
 
 // MakeBufferLine creates a new Line with the given text.
@@ -26,26 +33,42 @@ func MakeBufferLine(buffer *Buffer, text string) (bl Line) {
 	return bl
 }
 
+// Length returns the length of the line in runes.
+func (l *Line) Length() int {
+	return len(l.Runes)
+}
+
+// Slice returns the text of the line between two rune indices.
+func (l *Line) Slice(start, end int) string {
+	return string(l.Runes[start:end])
+}
+
+// RuneIndex converts a byte index into the line's text to a rune index.
+func (l *Line) RuneIndex(i int) int {
+	return utf8.RuneCountInString(l.Text[:i])
+}
+
 // InsertRune inserts a rune into the line at the given index.
 func (l *Line) InsertRune(i int, c rune) {
 	tabSize := l.Buffer.Config.TabSize
+	head, tail := l.Slice(0, i), l.Slice(i, l.Length())
 
 	// If a tab is being inserted and the editor is using soft tabs insert a
 	// tab's width worth of spaces instead.
 	if c == '\t' && l.Buffer.Config.UseSoftTabs {
-		l.Text = l.Text[:i] + strings.Repeat(" ", tabSize) + l.Text[i:]
+		l.Text = head + strings.Repeat(" ", tabSize) + tail
 		l.Buffer.CursorX += tabSize - 1
 	} else {
-		l.Text = l.Text[:i] + string(c) + l.Text[i:]
+		l.Text = head + string(c) + tail
 	}
 
 	l.Update()
 }
 
-// DeleteRune deletes a rune from the line at the given index.
-func (l *Line) DeleteRune(i int) {
-	if i >= 0 && i < len(l.Text) {
-		l.Text = l.Text[:i] + l.Text[i+1:]
+// DeleteRunes deletes the runes between two indices from the line.
+func (l *Line) DeleteRunes(start, end int) {
+	if start >= 0 && start < end && end <= l.Length() {
+		l.Text = l.Slice(0, start) + l.Slice(end, l.Length())
 		l.Update()
 	}
 }
@@ -56,45 +79,86 @@ func (l *Line) AppendString(s string) {
 	l.Update()
 }
 
-// Update refreshes the DisplayText field.
+// Update refreshes the Runes field after the line's text has changed. Since
+// highlighting depends on the lines before it, the line is highlighted again
+// by the buffer once the edit it is part of is finished.
 func (l *Line) Update() {
+	l.Runes = []rune(l.Text)
+	l.TokenTypes = make([]TokenType, len(l.Runes))
+	l.isHighlighted = false
+}
 
-	// Expand tabs to spaces.
-	tabFill := strings.Repeat(" ", l.Buffer.Config.TabSize)
-	l.DisplayText = strings.ReplaceAll(l.Text, "\t", tabFill)
-
-	l.TokenTypes = make([]TokenType, len(l.DisplayText))
-	if l.Buffer.Config.UseHighlighting {
-		switch l.Buffer.FileType {
-		case support.FileTypeC, support.FileTypeCPP:
-			l.Highlight(&syntax.LanguageC)
-		case support.FileTypeGo:
-			l.Highlight(&syntax.LanguageGo)
-		}
+// PreviousBoundary returns the index of the character before index x. Zero-width
+// runes, such as combining marks, are kept together with the rune before them.
+func (l *Line) PreviousBoundary(x int) int {
+	if x > 0 {
+		x--
 	}
+
+	for x > 0 && support.RuneWidth(l.Runes[x]) == 0 {
+		x--
+	}
+
+	return x
 }
 
-// AdjustedX returns the cursor's X position compensated for tab expansion.
-func (l *Line) AdjustedX(x int) int {
-	tabSize := l.Buffer.Config.TabSize
-	delta := 0
+// NextBoundary returns the index of the character after index x. Zero-width
+// runes, such as combining marks, are kept together with the rune before them.
+func (l *Line) NextBoundary(x int) int {
+	if x < l.Length() {
+		x++
+	}
 
-	for _, c := range l.Text[:x] {
-		if c == '\t' {
-			delta += (tabSize - 1) - (delta % tabSize)
-		}
+	for x < l.Length() && support.RuneWidth(l.Runes[x]) == 0 {
+		x++
+	}
+
+	return x
+}
+
+// ColumnWidth returns the number of columns the rune at index x occupies when
+// it is displayed starting at the given column.
+func (l *Line) ColumnWidth(x, column int) int {
+	if l.Runes[x] == '\t' {
+		tabSize := l.Buffer.Config.TabSize
+		return tabSize - column%tabSize
+	}
+
+	return support.RuneWidth(l.Runes[x])
+}
 
-		delta++
+// AdjustedX returns the display column of the rune at index x, compensated for
+// tab expansion and the width of wide characters.
+func (l *Line) AdjustedX(x int) int {
+	column := 0
+	for i := 0; i < x && i < l.Length(); i++ {
+		column += l.ColumnWidth(i, column)
 	}
 
-	return delta
+	return column
 }
 
-// IndentLength gets the line's level of indentation in columns.
+// IndentLength gets the line's level of indentation in runes.
 func (l *Line) IndentLength() (indent int) {
-	for j := 0; j < len(l.Text) && (l.Text[j] == ' ' || l.Text[j] == '\t'); j++ {
+	for indent < l.Length() && (l.Runes[indent] == ' ' || l.Runes[indent] == '\t') {
 		indent++
 	}
 
 	return indent
 }
+
+// IndexAtColumn returns the index of the rune displayed at the given column, or
+// the length of the line if the column is past its end.
+func (l *Line) IndexAtColumn(column int) int {
+	current := 0
+	for i := 0; i < l.Length(); i++ {
+		w := l.ColumnWidth(i, current)
+		if w > 0 && current+w > column {
+			return i
+		}
+
+		current += w
+	}
+
+	return l.Length()
+}
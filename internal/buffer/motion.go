@@ -0,0 +1,177 @@
+package buffer
+
+import (
+	"strings"
+
+	"github.com/jonpalmisc/atto/internal/support"
+)
+
+// brackets maps each opening bracket to its closing bracket.
+var brackets = map[rune]rune{'(': ')', '[': ']', '{': '}'}
+
+// words returns the rules for which runes words are made of, from the config.
+func (b *Buffer) words() support.Words {
+	return support.Words{Extra: b.Config.WordCharacters, Subwords: b.Config.SubwordMotion}
+}
+
+// PreviousWordStart returns the position of the start of the word before the
+// cursor on the same line. At the start of a line, it returns the end of the
+// previous line instead.
+func (b *Buffer) PreviousWordStart() Position {
+	if b.CursorX == 0 {
+		if b.CursorY == 1 {
+			return b.cursor()
+		}
+
+		return Position{X: b.PreviousLine().Length(), Y: b.CursorY - 1}
+	}
+
+	return Position{X: b.words().PreviousWordStart(b.FocusedLine().Runes, b.CursorX), Y: b.CursorY}
+}
+
+// NextWordEnd returns the position just past the end of the word after the
+// cursor on the same line. At the end of a line, it returns the start of the
+// next line instead.
+func (b *Buffer) NextWordEnd() Position {
+	if b.CursorX == b.FocusedLine().Length() {
+		if b.CursorY == b.Length() {
+			return b.cursor()
+		}
+
+		return Position{X: 0, Y: b.CursorY + 1}
+	}
+
+	return Position{X: b.words().NextWordEnd(b.FocusedLine().Runes, b.CursorX), Y: b.CursorY}
+}
+
+// isBlank tells whether the line at index i contains only whitespace.
+func (b *Buffer) isBlank(i int) bool {
+	return strings.TrimSpace(b.Lines[i].Text) == ""
+}
+
+// PreviousParagraph returns the number of the blank line before the paragraph
+// above the cursor, or the first line if there is none.
+func (b *Buffer) PreviousParagraph() int {
+	i := b.CursorY - 2
+	for i > 0 && b.isBlank(i) {
+		i--
+	}
+
+	for i > 0 && !b.isBlank(i) {
+		i--
+	}
+
+	if i < 0 {
+		return 1
+	}
+
+	return i + 1
+}
+
+// NextParagraph returns the number of the blank line after the paragraph below
+// the cursor, or the last line if there is none.
+func (b *Buffer) NextParagraph() int {
+	i := b.CursorY
+	for i < b.Length()-1 && b.isBlank(i) {
+		i++
+	}
+
+	for i < b.Length()-1 && !b.isBlank(i) {
+		i++
+	}
+
+	if i >= b.Length() {
+		return b.Length()
+	}
+
+	return i + 1
+}
+
+// isCode tells whether the rune at index x of a line is code rather than part
+// of a string or comment.
+func (l *Line) isCode(x int) bool {
+	if x >= len(l.TokenTypes) {
+		return true
+	}
+
+	t := l.TokenTypes[x]
+	return t != TokenTypeString && t != TokenTypeComment
+}
+
+// bracketAt returns the bracket at index x of a line, the bracket it pairs
+// with, and whether it opens a pair.
+func (l *Line) bracketAt(x int) (rune, rune, bool, bool) {
+	if x < 0 || x >= l.Length() {
+		return 0, 0, false, false
+	}
+
+	c := l.Runes[x]
+	if closing, ok := brackets[c]; ok {
+		return c, closing, true, true
+	}
+
+	for opening, closing := range brackets {
+		if c == closing {
+			return c, opening, false, true
+		}
+	}
+
+	return 0, 0, false, false
+}
+
+// MatchingBracket returns the position of the bracket which pairs with the one
+// under the cursor, or just before it if there is none under the cursor.
+func (b *Buffer) MatchingBracket() (Position, bool) {
+	if p, ok := b.matchBracket(b.cursor()); ok {
+		return p, true
+	}
+
+	return b.matchBracket(Position{X: b.CursorX - 1, Y: b.CursorY})
+}
+
+// matchBracket returns the position of the bracket which pairs with the one at
+// a position. When the bracket is code, brackets in strings and comments are
+// ignored.
+func (b *Buffer) matchBracket(p Position) (Position, bool) {
+	x := p.X
+	c, pair, opens, ok := b.Lines[p.Y-1].bracketAt(x)
+	if !ok {
+		return Position{}, false
+	}
+
+	code := b.Lines[p.Y-1].isCode(x)
+	step := 1
+	if !opens {
+		step = -1
+	}
+
+	depth := 0
+	for y := p.Y - 1; y >= 0 && y < b.Length(); y += step {
+		line := &b.Lines[y]
+		if y != p.Y-1 {
+			x = 0
+			if step < 0 {
+				x = line.Length() - 1
+			}
+		}
+
+		for ; x >= 0 && x < line.Length(); x += step {
+			if code && !line.isCode(x) {
+				continue
+			}
+
+			switch line.Runes[x] {
+			case c:
+				depth++
+			case pair:
+				depth--
+			}
+
+			if depth == 0 {
+				return Position{X: x, Y: y + 1}, true
+			}
+		}
+	}
+
+	return Position{}, false
+}
@@ -1,5 +1,10 @@
 package buffer
 
+import (
+	"strings"
+	"unicode/utf8"
+)
+
 // InsertLine inserts a new line to the buffer at the given index.
 func (b *Buffer) InsertLine(i int, text string) {
 	if b.IsReadOnly {
@@ -8,11 +13,14 @@ func (b *Buffer) InsertLine(i int, text string) {
 
 	// Ensure the index we are trying to insert at is valid.
 	if i >= 0 && i <= b.Length() {
+		b.beginEdit(EditKindOther, i, 0)
 
 		// https://github.com/golang/go/wiki/SliceTricks
 		b.Lines = append(b.Lines, Line{})
 		copy(b.Lines[i+1:], b.Lines[i:])
 		b.Lines[i] = MakeBufferLine(b, text)
+
+		b.endEdit(1)
 	}
 }
 
@@ -23,8 +31,9 @@ func (b *Buffer) RemoveLine(i int) {
 	}
 
 	if i >= 0 && i < b.Length() {
+		b.beginEdit(EditKindOther, i, 1)
 		b.Lines = append(b.Lines[:i], b.Lines[i+1:]...)
-		b.IsDirty = true
+		b.endEdit(0)
 	}
 }
 
@@ -34,35 +43,74 @@ func (b *Buffer) BreakLine() {
 		return
 	}
 
+	b.beginEdit(EditKindOther, b.CursorY-1, 1)
+
 	if b.CursorX == 0 {
 		b.InsertLine(b.CursorY-1, "")
 		b.CursorX = 0
-	} else {
-		text := b.FocusedLine().Text
-		indent := b.FocusedLine().IndentLength()
+		b.CursorY++
+		b.endEdit(2)
+		return
+	}
+
+	line := b.FocusedLine()
+	indent := line.Slice(0, line.IndentLength())
+	head, tail := line.Slice(0, b.CursorX), line.Slice(b.CursorX, line.Length())
+	count := 2
 
-		b.InsertLine(b.CursorY, text[:indent]+text[b.CursorX:])
-		b.FocusedLine().Text = text[:b.CursorX]
-		b.FocusedLine().Update()
+	// With a syntax, the new line is indented as the openers and closers before
+	// the cursor call for. If the line is broken between an opener and a
+	// closer, the closer is moved onto a line of its own after the new one.
+	if b.Syntax != nil {
+		tail = strings.TrimLeft(tail, " \t")
+		rest := MakeBufferLine(b, tail)
 
-		b.CursorX = indent
+		if b.opensIndent(line, b.CursorX) && b.closesIndent(&rest) {
+			b.InsertLine(b.CursorY, indent+tail)
+			tail = ""
+			count++
+		}
+
+		indent = b.indentText(b.indentAfter(b.CursorY-1, b.CursorX))
 	}
 
+	b.InsertLine(b.CursorY, indent+tail)
+	b.FocusedLine().Text = head
+	b.FocusedLine().Update()
+
+	b.CursorX = utf8.RuneCountInString(indent)
 	b.CursorY++
-	b.IsDirty = true
+	b.endEdit(count)
 }
 
 // InsertRune inserts a rune at the cursor's position.
 func (b *Buffer) InsertRune(c rune) {
-	if b.IsReadOnly {
+	if b.IsReadOnly || !IsInsertable(c) {
 		return
 	}
 
-	if IsInsertable(c) {
-		b.FocusedLine().InsertRune(b.CursorX, c)
+	// Typing a closing rune right before the same rune steps over it, so that
+	// typing out a pair which was closed automatically does not close it twice.
+	if b.typesOver(c) {
 		b.CursorX++
-		b.IsDirty = true
+		return
+	}
+
+	b.beginEdit(EditKindInsert, b.CursorY-1, 1)
+	b.FocusedLine().InsertRune(b.CursorX, c)
+	b.CursorX++
+
+	if closing, ok := b.pairCloser(c); ok && b.shouldPair(c, closing) {
+		b.FocusedLine().InsertRune(b.CursorX, closing)
 	}
+
+	// Typing a closer at the start of a line takes away a level of its
+	// indentation.
+	if b.Syntax != nil && b.Syntax.IsIndentCloser(c) && b.CursorX-1 == b.FocusedLine().IndentLength() {
+		b.dedent()
+	}
+
+	b.endEdit(1)
 }
 
 // DeleteRune deletes the rune to the left of the cursor.
@@ -74,14 +122,51 @@ func (b *Buffer) DeleteRune() {
 	if b.CursorX == 0 && b.CursorY-1 == 0 {
 		return
 	} else if b.CursorX > 0 {
-		b.FocusedLine().DeleteRune(b.CursorX - 1)
-		b.CursorX--
+		b.beginEdit(EditKindDelete, b.CursorY-1, 1)
+
+		// Combining marks are deleted together with the rune before them.
+		x := b.FocusedLine().PreviousBoundary(b.CursorX)
+
+		// Deleting the opening rune of an empty pair deletes the closing one
+		// as well.
+		end := b.CursorX
+		if b.isEmptyPair() {
+			end++
+		}
+
+		// When using soft tabs, spaces in the indentation are deleted back to
+		// the previous tab stop, taking away a whole level of indentation.
+		if head := b.FocusedLine().Slice(0, b.CursorX); b.Config.UseSoftTabs && strings.Trim(head, " ") == "" {
+			x = b.CursorX - (b.CursorX-1)%b.Config.TabSize - 1
+		}
+
+		b.FocusedLine().DeleteRunes(x, end)
+		b.CursorX = x
+
+		b.endEdit(1)
 	} else {
-		b.CursorX = len(b.Lines[b.CursorY-2].Text)
+		b.beginEdit(EditKindOther, b.CursorY-2, 2)
+		b.CursorX = b.Lines[b.CursorY-2].Length()
 		b.Lines[b.CursorY-2].AppendString(b.FocusedLine().Text)
 		b.RemoveLine(b.CursorY - 1)
 		b.CursorY--
+		b.endEdit(1)
+	}
+}
+
+// DeletePreviousWord deletes the text from the start of the word before the
+// cursor up to the cursor. At the start of a line, it joins the line to the
+// previous one instead.
+func (b *Buffer) DeletePreviousWord() {
+	if start := b.PreviousWordStart(); start != b.cursor() {
+		b.DeleteRange(start, b.cursor())
 	}
+}
 
-	b.IsDirty = true
+// DeleteNextWord deletes the text from the cursor up to the end of the word
+// after it. At the end of a line, it joins the next line to it instead.
+func (b *Buffer) DeleteNextWord() {
+	if end := b.NextWordEnd(); end != b.cursor() {
+		b.DeleteRange(b.cursor(), end)
+	}
 }
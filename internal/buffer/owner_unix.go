@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package buffer
+
+import (
+	"os"
+	"syscall"
+)
+
+// copyOwner gives the file at the given path the same owner and group as
+// another file. It fails silently, since only privileged users may give away
+// files.
+func copyOwner(path string, info os.FileInfo) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		os.Chown(path, int(stat.Uid), int(stat.Gid))
+	}
+}
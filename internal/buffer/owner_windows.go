@@ -0,0 +1,6 @@
+package buffer
+
+import "os"
+
+// copyOwner does nothing, since file ownership works differently on Windows.
+func copyOwner(path string, info os.FileInfo) {}
@@ -0,0 +1,87 @@
+package buffer
+
+import "unicode"
+
+// pairCloser returns the rune which closes a pair opened by a rune, if the
+// rune opens a pair and auto-pairing is enabled for the buffer's syntax.
+func (b *Buffer) pairCloser(c rune) (rune, bool) {
+	if !b.Config.AutoPairs || b.Syntax == nil {
+		return 0, false
+	}
+
+	return b.Syntax.PairCloser(c)
+}
+
+// isPairCloser tells whether a rune closes a pair and auto-pairing is enabled
+// for the buffer's syntax.
+func (b *Buffer) isPairCloser(c rune) bool {
+	return b.Config.AutoPairs && b.Syntax != nil && b.Syntax.IsPairCloser(c)
+}
+
+// runeAt returns the rune at index x of the line the cursor is on, or zero if
+// x is outside of the line.
+func (b *Buffer) runeAt(x int) rune {
+	if line := b.FocusedLine(); x >= 0 && x < line.Length() {
+		return line.Runes[x]
+	}
+
+	return 0
+}
+
+// typesOver tells whether typing a rune at the cursor should step over the
+// same rune rather than insert another, which is the case for closing runes.
+func (b *Buffer) typesOver(c rune) bool {
+	return b.isPairCloser(c) && b.runeAt(b.CursorX) == c
+}
+
+// shouldPair tells whether typing a rune which opens a pair at the cursor
+// should insert its closing rune as well. Pairs are only closed before
+// whitespace, closing runes or the end of the line, and quotes, which close
+// themselves, are not closed right after a word, as in "don't".
+func (b *Buffer) shouldPair(c, closing rune) bool {
+	next := b.runeAt(b.CursorX)
+	if next != 0 && !unicode.IsSpace(next) && !b.isPairCloser(next) {
+		return false
+	}
+
+	if c == closing {
+		prev := b.runeAt(b.CursorX - 1)
+		return !b.words().IsWordRune(prev) && prev != c
+	}
+
+	return true
+}
+
+// isEmptyPair tells whether the cursor is between the two runes of a pair.
+func (b *Buffer) isEmptyPair() bool {
+	closing, ok := b.pairCloser(b.runeAt(b.CursorX - 1))
+	return ok && b.CursorX > 0 && b.runeAt(b.CursorX) == closing
+}
+
+// WrapSelection surrounds the selected text with a pair if the rune opens one,
+// keeping the text selected. It returns false if nothing was wrapped, such as
+// when there is no selection or the rune does not open a pair.
+func (b *Buffer) WrapSelection(c rune) bool {
+	start, end, ok := b.Selection()
+	closing, pairs := b.pairCloser(c)
+	if !ok || !pairs || b.IsReadOnly {
+		return false
+	}
+
+	b.BeginGroup()
+	b.Replace(end, end, string(closing))
+	b.Replace(start, start, string(c))
+	b.EndGroup()
+
+	// Keep the same text selected, which has moved over by the opening rune
+	// where it is on the same line.
+	start.X++
+	if end.Y == start.Y {
+		end.X++
+	}
+
+	b.Anchor, b.IsSelecting = start, true
+	b.SetCursor(end)
+
+	return true
+}
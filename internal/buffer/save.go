@@ -0,0 +1,169 @@
+package buffer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jonpalmisc/atto/internal/config"
+)
+
+const (
+
+	// BackupsOff disables backups.
+	BackupsOff = "off"
+
+	// BackupsSimple keeps the previous version of a file next to it, with a
+	// tilde appended to its name.
+	BackupsSimple = "simple"
+
+	// BackupsTimestamped keeps every previous version of a file in the backups
+	// folder, named after the file's full path and the time it was replaced.
+	BackupsTimestamped = "timestamped"
+)
+
+// writeFile replaces the file at the given path with new contents. The
+// contents are written to a temporary file in the same folder, which is then
+// renamed over the original, so that the original is never left truncated.
+// The original file's permissions and ownership are kept, and it is backed up
+// first according to the buffer's configuration.
+func (b *Buffer) writeFile(path string, data []byte) error {
+
+	// Write through symbolic links rather than replacing them.
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	var original os.FileInfo
+	if info, err := os.Stat(path); err == nil {
+		original = info
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	folder, name := filepath.Split(path)
+	if folder == "" {
+		folder = "."
+	}
+
+	f, err := ioutil.TempFile(folder, "."+name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file (%v)", err)
+	}
+
+	// Remove the temporary file if anything goes wrong before it is renamed.
+	tmpPath := f.Name()
+	renamed := false
+	defer func() {
+		if !renamed {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write temporary file (%v)", err)
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync temporary file (%v)", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file (%v)", err)
+	}
+
+	mode := os.FileMode(0644)
+	if original != nil {
+		mode = original.Mode().Perm()
+		copyOwner(tmpPath, original)
+	}
+
+	if err = os.Chmod(tmpPath, mode); err != nil {
+		return fmt.Errorf("failed to set permissions (%v)", err)
+	}
+
+	if original != nil {
+		if err = b.backUp(path, mode); err != nil {
+			return fmt.Errorf("failed to back up file (%v)", err)
+		}
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace file (%v)", err)
+	}
+	renamed = true
+
+	// Sync the folder so that the rename itself survives a crash. Not every
+	// platform supports this, so errors are ignored.
+	if dir, err := os.Open(folder); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	return nil
+}
+
+// backupPath returns the path to back up the file at the given path to, or an
+// empty string if backups are disabled.
+func (b *Buffer) backupPath(path string) (string, error) {
+	if b.Config == nil {
+		return "", nil
+	}
+
+	switch b.Config.BackupMode {
+	case BackupsSimple:
+		return path + "~", nil
+	case BackupsTimestamped:
+		folder, err := config.FolderPath("backups")
+		if err != nil {
+			return "", err
+		}
+
+		name, err := flattenPath(path)
+		if err != nil {
+			return "", err
+		}
+
+		stamp := time.Now().Format("20060102-150405")
+
+		return filepath.Join(folder, name+"."+stamp), nil
+	}
+
+	return "", nil
+}
+
+// flattenPath turns the absolute form of a path into a single file name, so
+// that files with the same name in different folders can be told apart.
+func flattenPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Replace(abs, string(filepath.Separator), "%", -1), nil
+}
+
+// backUp copies the file at the given path to its backup path, if backups are
+// enabled.
+func (b *Buffer) backUp(path string, mode os.FileMode) error {
+	backup, err := b.backupPath(path)
+	if err != nil || backup == "" {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(backup, data, mode)
+}
@@ -0,0 +1,69 @@
+package buffer
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Match is an occurrence of a search query within the buffer.
+type Match struct {
+	Start Position
+	End   Position
+}
+
+// FindAll returns every occurrence of a query in the buffer, in order. Queries
+// cannot span multiple lines.
+func (b *Buffer) FindAll(query string) []Match {
+	var matches []Match
+	if query == "" {
+		return matches
+	}
+
+	length := utf8.RuneCountInString(query)
+
+	for i := range b.Lines {
+		line := &b.Lines[i]
+
+		for x := 0; x <= len(line.Text); {
+			j := strings.Index(line.Text[x:], query)
+			if j < 0 {
+				break
+			}
+
+			start := Position{X: line.RuneIndex(x + j), Y: i + 1}
+			end := Position{X: start.X + length, Y: i + 1}
+			matches = append(matches, Match{Start: start, End: end})
+
+			x += j + len(query)
+		}
+	}
+
+	return matches
+}
+
+// FindPattern returns the first match of a regular expression at or after the
+// given position. Matches cannot span multiple lines. The submatch indices of
+// the match within its line are returned as well, for use with Expand.
+func (b *Buffer) FindPattern(re *regexp.Regexp, from Position) (m Match, submatches []int, ok bool) {
+	for y := from.Y; y <= b.Length(); y++ {
+		line := &b.Lines[y-1]
+
+		// The whole line is searched so that anchors keep their meaning.
+		for _, indices := range re.FindAllStringSubmatchIndex(line.Text, -1) {
+			start, end := line.RuneIndex(indices[0]), line.RuneIndex(indices[1])
+			if y == from.Y && start < from.X {
+				continue
+			}
+
+			m = Match{
+				Start: Position{X: start, Y: y},
+				End:   Position{X: end, Y: y},
+			}
+
+			return m, indices, true
+		}
+	}
+
+	return Match{}, nil, false
+}
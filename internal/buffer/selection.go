@@ -0,0 +1,139 @@
+package buffer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Before tells whether the position comes before another position.
+func (p Position) Before(other Position) bool {
+	return p.Y < other.Y || (p.Y == other.Y && p.X < other.X)
+}
+
+// StartSelection anchors a new selection at the cursor's position.
+func (v *View) StartSelection() {
+	v.Anchor = v.cursor()
+	v.IsSelecting = true
+}
+
+// ClearSelection deactivates the selection and unsets the mark.
+func (v *View) ClearSelection() {
+	v.IsSelecting = false
+	v.IsMarkSet = false
+}
+
+// SetMark starts a sticky selection which is extended by regular cursor
+// movement until it is cleared.
+func (v *View) SetMark() {
+	v.StartSelection()
+	v.IsMarkSet = true
+}
+
+// Selection returns the start and end of the selection in order. If there is
+// no selection or it is empty, ok will be false.
+func (v *View) Selection() (start, end Position, ok bool) {
+	if !v.IsSelecting {
+		return Position{}, Position{}, false
+	}
+
+	start, end = v.Anchor, v.cursor()
+	if end.Before(start) {
+		start, end = end, start
+	}
+
+	return start, end, start != end
+}
+
+// IsSelected tells whether the character at the given position is selected.
+func (v *View) IsSelected(p Position) bool {
+	start, end, ok := v.Selection()
+	if !ok {
+		return false
+	}
+
+	return !p.Before(start) && p.Before(end)
+}
+
+// TextInRange returns the text between two positions, with lines separated by
+// newline characters.
+func (b *Buffer) TextInRange(start, end Position) string {
+	first, last := &b.Lines[start.Y-1], &b.Lines[end.Y-1]
+	if start.Y == end.Y {
+		return first.Slice(start.X, end.X)
+	}
+
+	parts := []string{first.Slice(start.X, first.Length())}
+	for y := start.Y; y < end.Y-1; y++ {
+		parts = append(parts, b.Lines[y].Text)
+	}
+	parts = append(parts, last.Slice(0, end.X))
+
+	return strings.Join(parts, "\n")
+}
+
+// SelectedText returns the text inside of the selection.
+func (b *Buffer) SelectedText() string {
+	start, end, ok := b.Selection()
+	if !ok {
+		return ""
+	}
+
+	return b.TextInRange(start, end)
+}
+
+// Replace replaces the text between two positions with a block of text, which
+// may span multiple lines. The cursor is moved to the end of the new text.
+func (b *Buffer) Replace(start, end Position, text string) {
+	if b.IsReadOnly {
+		return
+	}
+
+	count := end.Y - start.Y + 1
+	b.beginEdit(EditKindOther, start.Y-1, count)
+
+	head := b.Lines[start.Y-1].Slice(0, start.X)
+	tail := b.Lines[end.Y-1].Slice(end.X, b.Lines[end.Y-1].Length())
+
+	texts := strings.Split(text, "\n")
+	last := len(texts) - 1
+	x := utf8.RuneCountInString(texts[last])
+
+	texts[0] = head + texts[0]
+	if last == 0 {
+		x += start.X
+	}
+	texts[last] += tail
+
+	b.replaceLines(start.Y-1, count, texts)
+	b.CursorX, b.CursorY = x, start.Y+last
+
+	b.endEdit(len(texts))
+}
+
+// DeleteRange deletes the text between two positions and moves the cursor to
+// the start of the deleted range.
+func (b *Buffer) DeleteRange(start, end Position) {
+	b.Replace(start, end, "")
+}
+
+// DeleteSelection deletes the selected text and clears the selection. It
+// returns false if there was nothing to delete.
+func (b *Buffer) DeleteSelection() bool {
+	start, end, ok := b.Selection()
+	b.ClearSelection()
+
+	if !ok || b.IsReadOnly {
+		return false
+	}
+
+	b.DeleteRange(start, end)
+	return true
+}
+
+// InsertText inserts a block of text, which may span multiple lines, at the
+// cursor's position. The cursor is moved to the end of the inserted text.
+func (b *Buffer) InsertText(text string) {
+	if text != "" {
+		b.Replace(b.cursor(), b.cursor(), text)
+	}
+}
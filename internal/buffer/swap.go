@@ -0,0 +1,170 @@
+package buffer
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonpalmisc/atto/internal/config"
+)
+
+// maxSwapFiles is the number of swap files which can exist for a file at once,
+// such as when a swap file left behind by an earlier session is kept.
+const maxSwapFiles = 10
+
+// swapPath returns the path to the n-th swap file for the file at the given
+// path. The first swap file has no number in its name.
+func swapPath(path string, n int) (string, error) {
+	folder, err := config.FolderPath("swap")
+	if err != nil {
+		return "", err
+	}
+
+	name, err := flattenPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	if n > 0 {
+		name += "." + strconv.Itoa(n)
+	}
+
+	return filepath.Join(folder, name+".swp"), nil
+}
+
+// findSwap returns the path to the most recent swap file left behind for the
+// file at the given path, or an empty string if there is none.
+func findSwap(path string) string {
+	var found string
+	var newest time.Time
+
+	for n := 0; n < maxSwapFiles; n++ {
+		swap, err := swapPath(path, n)
+		if err != nil {
+			return ""
+		}
+
+		if info, err := os.Stat(swap); err == nil && (found == "" || info.ModTime().After(newest)) {
+			found, newest = swap, info.ModTime()
+		}
+	}
+
+	return found
+}
+
+// ownSwapPath returns the path to write the buffer's swap file to. Swap files
+// which the buffer did not write, such as the one found when it was created,
+// are left alone.
+func (b *Buffer) ownSwapPath() (string, error) {
+	for n := 0; n < maxSwapFiles; n++ {
+		swap, err := swapPath(b.Path, n)
+		if err != nil {
+			return "", err
+		}
+
+		if swap == b.swapFile {
+			return swap, nil
+		} else if swap == b.Swap {
+			continue
+		}
+
+		if _, err := os.Stat(swap); os.IsNotExist(err) {
+			return swap, nil
+		}
+	}
+
+	return "", errors.New("too many swap files")
+}
+
+// WriteSwap writes the buffer's contents to its swap file if they changed
+// since the swap file was last written, so that unsaved edits can be recovered
+// if the editor exits unexpectedly. The swap file is removed once the buffer
+// is no longer dirty.
+func (b *Buffer) WriteSwap() error {
+	if !b.isSwapStale || b.IsReadOnly {
+		return nil
+	} else if !b.IsDirty {
+		b.RemoveSwap()
+		return nil
+	}
+
+	swap, err := b.ownSwapPath()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(swap), 0700); err != nil {
+		return err
+	}
+
+	text := formatText(b.lineTexts(0, b.Length()), b.Format)
+	if err = ioutil.WriteFile(swap, text, 0600); err != nil {
+		return err
+	}
+
+	// The buffer may have been saved under a new name since the last swap
+	// file was written.
+	if b.swapFile != "" && b.swapFile != swap {
+		os.Remove(b.swapFile)
+	}
+
+	b.swapFile, b.isSwapStale = swap, false
+	return nil
+}
+
+// RemoveSwap removes the swap file written for the buffer, if there is one.
+func (b *Buffer) RemoveSwap() {
+	if b.swapFile != "" {
+		os.Remove(b.swapFile)
+	}
+
+	b.swapFile, b.isSwapStale = "", false
+}
+
+// ReadSwap reads the lines of the swap file found when the buffer was created.
+func (b *Buffer) ReadSwap() ([]string, error) {
+	data, err := ioutil.ReadFile(b.Swap)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, _ := parseText(data)
+	return lines, nil
+}
+
+// Recover replaces the buffer's contents with those of the swap file found
+// when the buffer was created. The recovery can be undone like any other edit.
+func (b *Buffer) Recover() error {
+	data, err := ioutil.ReadFile(b.Swap)
+	if err != nil {
+		return err
+	}
+
+	lines, format := parseText(data)
+	if format != b.Format {
+		b.setFormat(format)
+	}
+
+	// Only replace the text if it differs, so that recovering a swap file with
+	// the same contents as the file leaves the buffer clean.
+	if strings.Join(lines, "\n") != strings.Join(b.lineTexts(0, b.Length()), "\n") {
+		last := b.Length() - 1
+		b.Replace(Position{X: 0, Y: 1}, Position{X: b.Lines[last].Length(), Y: last + 1}, strings.Join(lines, "\n"))
+		b.SetCursor(Position{X: 0, Y: 1})
+	}
+
+	// The swap file now belongs to the buffer, and is rewritten or removed
+	// along with the buffer's own swap file.
+	b.swapFile, b.Swap, b.isSwapStale = b.Swap, "", true
+	return nil
+}
+
+// DiscardSwap removes the swap file found when the buffer was created.
+func (b *Buffer) DiscardSwap() {
+	os.Remove(b.Swap)
+	b.Swap = ""
+}
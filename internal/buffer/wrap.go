@@ -0,0 +1,39 @@
+package buffer
+
+// WrapRows splits the line into rows of at most width display columns for soft
+// wrapping, and returns the index of the rune each row starts at. Rows are
+// broken after the last space which fits where possible, so that words are not
+// split across rows.
+func (l *Line) WrapRows(width int) []int {
+	rows := []int{0}
+	if width < 1 {
+		return rows
+	}
+
+	// The display column of each rune, since tabs and wide runes make them
+	// differ from the indices.
+	columns := make([]int, l.Length()+1)
+	for i := range l.Runes {
+		columns[i+1] = columns[i] + l.ColumnWidth(i, columns[i])
+	}
+
+	start, space := 0, -1
+	for i := range l.Runes {
+		for columns[i+1]-columns[start] > width && i > start {
+			if space > start {
+				start = space
+			} else {
+				start = i
+			}
+
+			rows = append(rows, start)
+			space = -1
+		}
+
+		if c := l.Runes[i]; c == ' ' || c == '\t' {
+			space = i + 1
+		}
+	}
+
+	return rows
+}
@@ -0,0 +1,39 @@
+package buffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWrapRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		rows  []int
+	}{
+		{"empty line", "", 10, []int{0}},
+		{"no width", "hello world", 0, []int{0}},
+		{"fits", "hello world", 20, []int{0}},
+		{"fits exactly", "hello world", 11, []int{0}},
+		{"breaks after a space", "hello world", 8, []int{0, 6}},
+		{"space at the end of a row", "hello world", 6, []int{0, 6}},
+		{"several rows", "one two three four", 9, []int{0, 8, 14}},
+		{"several spaces", "aa  bb", 3, []int{0, 3}},
+		{"long word", "abcdefghij", 4, []int{0, 4, 8}},
+		{"long word after a space", "ab cdefghij", 4, []int{0, 3, 7}},
+		{"tab", "\tab", 4, []int{0, 1}},
+		{"tab after text", "a\tb", 4, []int{0, 2}},
+		{"wide runes", "日本語", 4, []int{0, 2}},
+		{"wide runes wider than the row", "日本語", 1, []int{0, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBuffer(tt.text)
+			if rows := b.Lines[0].WrapRows(tt.width); !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("WrapRows(%v) of %q = %v, want %v", tt.width, tt.text, rows, tt.rows)
+			}
+		})
+	}
+}
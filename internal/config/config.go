@@ -26,13 +26,60 @@ func configPath() (string, error) {
 	return filepath.Join(attoFolder, "config.yml"), nil
 }
 
+// FolderPath returns the path to a folder inside of the Atto folder.
+func FolderPath(name string) (string, error) {
+	attoFolder, err := attoFolderPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(attoFolder, name), nil
+}
+
 // Config holds the editor's configuration and settings.
 type Config struct {
 	TabSize         int
 	UseSoftTabs     bool
 	UseHighlighting bool
-	ShowFullPaths bool
-	Use24HourTime bool
+	ShowFullPaths   bool
+	Use24HourTime   bool
+
+	// How line numbers are shown in the gutter ("off", "absolute" or
+	// "relative").
+	LineNumbers string
+
+	// Whether long lines are wrapped onto several rows instead of scrolling
+	// horizontally, and the column they are wrapped at if the window is wider
+	// (0 always wraps at the edge of the window).
+	SoftWrap   bool
+	WrapColumn int
+
+	// The runes besides letters and digits which words are made of when moving
+	// or deleting by words, and whether words written in camelCase are split
+	// into their parts.
+	WordCharacters string
+	SubwordMotion  bool
+
+	// Whether brackets and quotes are closed automatically as they are typed,
+	// in languages whose syntax defines pairs.
+	AutoPairs bool
+
+	// How the previous version of a file is backed up when it is saved
+	// ("off", "simple" or "timestamped").
+	BackupMode string
+
+	// Whether buffers without unsaved changes are reloaded without asking when
+	// their files are changed by other programs.
+	AutoReload bool
+
+	// Key bindings, mapping sequences of keys such as "ctrl+x ctrl+s" to
+	// command lines. They are added to the default bindings.
+	KeyBindings map[string]string
+
+	// The name of the color theme, loaded from the themes folder, and the
+	// color mode ("auto", "16", "256" or "truecolor").
+	Theme     string
+	ColorMode string
 }
 
 // Default returns the default configuration.
@@ -41,8 +88,18 @@ func Default() Config {
 		TabSize:         4,
 		UseSoftTabs:     false,
 		UseHighlighting: true,
-		ShowFullPaths: false,
-		Use24HourTime: false,
+		ShowFullPaths:   false,
+		Use24HourTime:   false,
+		LineNumbers:     "off",
+		SoftWrap:        false,
+		WrapColumn:      0,
+		WordCharacters:  "_",
+		SubwordMotion:   false,
+		AutoPairs:       true,
+		BackupMode:      "off",
+		AutoReload:      false,
+		Theme:           "default",
+		ColorMode:       "auto",
 	}
 }
 
@@ -94,7 +151,8 @@ func Load() (Config, error) {
 	}
 
 	// Unmarshal the YAML & return the default config if there is an error.
-	config := Config{}
+	// Options missing from the file keep their default values.
+	config := Default()
 	err = yaml.Unmarshal(yml, &config)
 	if err != nil {
 		return Default(), err
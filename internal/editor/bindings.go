@@ -0,0 +1,289 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Binding maps a sequence of keys to a command line, which is run when the
+// keys are pressed.
+type Binding struct {
+	Keys      []Chord
+	Command   string
+	IsDefault bool
+}
+
+// defaultBindings are the key bindings used unless the user's config changes
+// them, in the order they are listed on the help screen.
+var defaultBindings = [][2]string{
+	{"ctrl+r", "open"},
+	{"ctrl+q", "files"},
+	{"ctrl+o", "save"},
+	{"ctrl+x", "close"},
+	{"ctrl+t", "lineending"},
+	{"ctrl+p", "next"},
+	{"ctrl+l", "prev"},
+	{"ctrl+d", "buffers"},
+	{"ctrl+w s", "split"},
+	{"ctrl+w v", "vsplit"},
+	{"ctrl+w q", "closepane"},
+	{"ctrl+w w", "nextpane"},
+	{"ctrl+w p", "prevpane"},
+	{"ctrl+j", "goto"},
+	{"ctrl+a", "linestart"},
+	{"ctrl+e", "lineend"},
+	{"alt+b", "wordleft"},
+	{"alt+f", "wordright"},
+	{"alt+{", "paragraphup"},
+	{"alt+}", "paragraphdown"},
+	{"alt+<", "bufferstart"},
+	{"alt+>", "bufferend"},
+	{"ctrl+]", "matchbracket"},
+	{"alt+backspace", "deletewordleft"},
+	{"alt+d", "deletewordright"},
+	{"ctrl+f", "find"},
+	{"ctrl+g", "findnext"},
+	{"ctrl+b", "findprev"},
+	{"ctrl+\\", "replace"},
+	{"ctrl+space", "mark"},
+	{"esc", "deselect"},
+	{"ctrl+c", "copy"},
+	{"ctrl+k", "cut"},
+	{"ctrl+v", "paste"},
+	{"ctrl+z", "undo"},
+	{"ctrl+y", "redo"},
+	{"ctrl+n", "commandline"},
+	{"f1", "help"},
+}
+
+// keyNode is a node of the tree of key sequences. Nodes with a binding end a
+// sequence, while the others are prefixes of longer sequences.
+type keyNode struct {
+	binding  *Binding
+	children map[Chord]*keyNode
+}
+
+// isPrefix tells whether a sequence of keys starts with another one.
+func isPrefix(prefix, keys []Chord) bool {
+	if len(prefix) > len(keys) {
+		return false
+	}
+
+	for i := range prefix {
+		if prefix[i] != keys[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadBindings combines the default key bindings with the user's, which may
+// add new bindings or unbind keys by binding them to "none". Problems with the
+// user's bindings, such as conflicts, are collected in BindingProblems.
+func (e *Editor) loadBindings() {
+	e.Bindings, e.BindingProblems = nil, nil
+
+	byKeys := map[string]*Binding{}
+	add := func(b *Binding) {
+		name := keysString(b.Keys, false)
+		if old, ok := byKeys[name]; ok {
+			e.removeBinding(old)
+		}
+
+		byKeys[name] = b
+		if b.Command != "" {
+			e.Bindings = append(e.Bindings, b)
+		}
+	}
+
+	for _, d := range defaultBindings {
+		keys, _ := ParseKeys(d[0])
+		add(&Binding{Keys: keys, Command: d[1], IsDefault: true})
+	}
+
+	// Apply the user's bindings in a fixed order, so that problems are always
+	// reported the same way.
+	var written []string
+	for k := range e.Config.KeyBindings {
+		written = append(written, k)
+	}
+	sort.Strings(written)
+
+	var user []*Binding
+	for _, w := range written {
+		command := strings.TrimSpace(e.Config.KeyBindings[w])
+
+		keys, err := ParseKeys(w)
+		if err != nil {
+			e.addBindingProblem("%v", err)
+			continue
+		}
+
+		name := keysString(keys, false)
+		if old, ok := byKeys[name]; ok && !old.IsDefault {
+			e.addBindingProblem("\"%v\" is the same key as \"%v\" and replaces its binding", w, name)
+		} else if aliasesKey(w, keys) {
+			e.addBindingProblem("\"%v\" is the same key as \"%v\" on most terminals", w, name)
+		}
+
+		if command == "none" || command == "" {
+			add(&Binding{Keys: keys})
+			continue
+		}
+
+		if fields := strings.Fields(command); FindCommand(fields[0]) == nil {
+			e.addBindingProblem("\"%v\" is bound to unknown command \"%v\"", w, fields[0])
+			continue
+		}
+
+		b := &Binding{Keys: keys, Command: command}
+		add(b)
+		user = append(user, b)
+	}
+
+	// A key sequence cannot be bound if it starts with another bound sequence,
+	// since the shorter one would always be run first. User bindings win over
+	// default ones, and earlier user bindings over later ones. Conflicts with
+	// later user bindings are left for when those are checked.
+	checked := map[*Binding]bool{}
+	for _, u := range user {
+		checked[u] = true
+		for i := 0; i < len(e.Bindings); i++ {
+			o := e.Bindings[i]
+			if o == u || (!isPrefix(o.Keys, u.Keys) && !isPrefix(u.Keys, o.Keys)) {
+				continue
+			}
+
+			if o.IsDefault {
+				e.addBindingProblem("\"%v\" conflicts with \"%v\", which is no longer bound to %v", keysString(u.Keys, false), keysString(o.Keys, false), o.Command)
+				e.Bindings = append(e.Bindings[:i], e.Bindings[i+1:]...)
+				i--
+			} else if checked[o] && e.removeBinding(u) {
+				e.addBindingProblem("\"%v\" conflicts with \"%v\" and was ignored", keysString(u.Keys, false), keysString(o.Keys, false))
+				break
+			}
+		}
+	}
+
+	// Build the tree of key sequences.
+	e.keymap = &keyNode{}
+	for _, b := range e.Bindings {
+		node := e.keymap
+		for _, c := range b.Keys {
+			if node.children == nil {
+				node.children = map[Chord]*keyNode{}
+			}
+			if node.children[c] == nil {
+				node.children[c] = &keyNode{}
+			}
+
+			node = node.children[c]
+		}
+
+		node.binding = b
+	}
+}
+
+// aliasesKey tells whether a written key sequence uses Ctrl with a key whose
+// control character is the same as another key's, such as Ctrl-I and Tab.
+func aliasesKey(written string, keys []Chord) bool {
+	for i, f := range strings.Fields(strings.ToLower(written)) {
+		if strings.Contains(f, "ctrl+") && !strings.Contains(keys[i].String(), "ctrl+") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeBinding removes a binding from the active bindings and returns whether
+// it was active.
+func (e *Editor) removeBinding(b *Binding) bool {
+	for i, o := range e.Bindings {
+		if o == b {
+			e.Bindings = append(e.Bindings[:i], e.Bindings[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// addBindingProblem records a problem with the user's key bindings.
+func (e *Editor) addBindingProblem(format string, args ...interface{}) {
+	e.BindingProblems = append(e.BindingProblems, fmt.Sprintf(format, args...))
+}
+
+// handleKey runs the command bound to a key event, keeping track of sequences
+// of keys as they are typed. It returns false if the key is not bound, so that
+// it can be handled as regular input.
+func (e *Editor) handleKey(event termbox.Event) bool {
+	chord := eventChord(event)
+
+	node := e.keymap
+	if e.keyState != nil {
+		node = e.keyState
+	}
+
+	next, ok := node.children[chord]
+	if !ok {
+		if e.keyState == nil {
+			return false
+		}
+
+		e.SetStatusMessage("%v is not bound.", keysString(append(e.pendingKeys, chord), true))
+		e.keyState, e.pendingKeys = nil, nil
+		return true
+	}
+
+	if next.binding != nil {
+
+		// Clear the hint showing the keys typed so far.
+		if e.pendingKeys != nil {
+			e.SetStatusMessage("")
+		}
+
+		e.keyState, e.pendingKeys = nil, nil
+		e.RunCommand(next.binding.Command)
+		return true
+	}
+
+	// Wait for the rest of the sequence.
+	e.keyState, e.pendingKeys = next, append(e.pendingKeys, chord)
+	e.SetStatusMessage("%v-", keysString(e.pendingKeys, true))
+	return true
+}
+
+// shortcutHelp returns the lines of the help screen which list the active key
+// bindings.
+func (e *Editor) shortcutHelp() []string {
+	width := 0
+	for _, b := range e.Bindings {
+		if w := len(keysString(b.Keys, true)); w > width {
+			width = w
+		}
+	}
+
+	var lines []string
+	for _, b := range e.Bindings {
+		description := b.Command
+		if c := FindCommand(b.Command); c != nil {
+			description = c.Description
+		}
+
+		lines = append(lines, fmt.Sprintf("    %-*v  %v", width, keysString(b.Keys, true), description))
+	}
+
+	if len(e.BindingProblems) > 0 {
+		lines = append(lines, "", "    The following problems were found with your key bindings:", "")
+		for _, p := range e.BindingProblems {
+			lines = append(lines, "      - "+p)
+		}
+	}
+
+	return lines
+}
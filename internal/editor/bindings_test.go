@@ -0,0 +1,192 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonpalmisc/atto/internal/buffer"
+	"github.com/jonpalmisc/atto/internal/config"
+	"github.com/nsf/termbox-go"
+)
+
+// newTestEditor creates an editor with the default configuration and the given
+// user key bindings, with a single buffer holding the given lines.
+func newTestEditor(bindings map[string]string, lines ...string) *Editor {
+	e := &Editor{Config: config.Default()}
+	e.Config.KeyBindings = bindings
+	e.loadBindings()
+	e.Buffers = []*buffer.Buffer{buffer.FromStrings(&e.Config, "test.txt", lines)}
+
+	return e
+}
+
+// boundCommand returns the command bound to a written key sequence, or an empty
+// string if the sequence is not bound.
+func boundCommand(t *testing.T, e *Editor, written string) string {
+	keys, err := ParseKeys(written)
+	if err != nil {
+		t.Fatalf("ParseKeys(%q) failed: %v", written, err)
+	}
+
+	node := e.keymap
+	for _, c := range keys {
+		if node = node.children[c]; node == nil {
+			return ""
+		}
+	}
+
+	if node.binding == nil {
+		return ""
+	}
+
+	return node.binding.Command
+}
+
+func TestLoadBindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     map[string]string
+		bound    map[string]string
+		problems int
+	}{
+		{
+			name:  "defaults",
+			bound: map[string]string{"ctrl+o": "save", "ctrl+w v": "vsplit", "f1": "help"},
+		},
+		{
+			name:  "ctrl+backspace is unbound by default",
+			bound: map[string]string{"ctrl+backspace": "", "ctrl+h": "", "backspace": ""},
+		},
+		{
+			name:  "binding ctrl+backspace",
+			user:  map[string]string{"ctrl+backspace": "deletewordleft"},
+			bound: map[string]string{"ctrl+h": "deletewordleft"},
+		},
+		{
+			name:  "unbinding",
+			user:  map[string]string{"ctrl+t": "none", "ctrl+w v": ""},
+			bound: map[string]string{"ctrl+t": "", "ctrl+w v": "", "ctrl+w s": "split"},
+		},
+		{
+			name:  "replacing a default",
+			user:  map[string]string{"ctrl+o": "close"},
+			bound: map[string]string{"ctrl+o": "close", "ctrl+x": "close"},
+		},
+		{
+			name:  "command with arguments",
+			user:  map[string]string{"f5": "set linenumbers relative"},
+			bound: map[string]string{"f5": "set linenumbers relative"},
+		},
+		{
+			name:     "unknown command",
+			user:     map[string]string{"f5": "nope"},
+			bound:    map[string]string{"f5": ""},
+			problems: 1,
+		},
+		{
+			name:     "invalid key",
+			user:     map[string]string{"meta+x": "save"},
+			problems: 1,
+		},
+		{
+			name:     "same key written twice",
+			user:     map[string]string{"ctrl+@": "save", "ctrl+space": "close"},
+			bound:    map[string]string{"ctrl+space": "close"},
+			problems: 1,
+		},
+		{
+			name:     "key aliased by the terminal",
+			user:     map[string]string{"ctrl+i": "save"},
+			bound:    map[string]string{"tab": "save"},
+			problems: 1,
+		},
+		{
+			name:     "prefix of default sequences",
+			user:     map[string]string{"ctrl+w": "close"},
+			bound:    map[string]string{"ctrl+w": "close", "ctrl+w s": "", "ctrl+w v": ""},
+			problems: 5,
+		},
+		{
+			name:     "sequence starting with a default",
+			user:     map[string]string{"ctrl+o x": "close"},
+			bound:    map[string]string{"ctrl+o": "", "ctrl+o x": "close"},
+			problems: 1,
+		},
+		{
+			name:     "conflicting user bindings",
+			user:     map[string]string{"f5": "save", "f5 f6": "close"},
+			bound:    map[string]string{"f5": "save", "f5 f6": ""},
+			problems: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.user, "")
+
+			for written, command := range tt.bound {
+				if got := boundCommand(t, e, written); got != command {
+					t.Errorf("%q is bound to %q, want %q", written, got, command)
+				}
+			}
+
+			if len(e.BindingProblems) != tt.problems {
+				t.Errorf("found %v problems, want %v: %q", len(e.BindingProblems), tt.problems, e.BindingProblems)
+			}
+		})
+	}
+}
+
+func TestHandleKey(t *testing.T) {
+	e := newTestEditor(nil, "")
+
+	tests := []struct {
+		name    string
+		event   termbox.Event
+		handled bool
+		status  string
+	}{
+		{"start of a sequence", termbox.Event{Key: termbox.KeyCtrlW}, true, "^W-"},
+		{"unbound end of a sequence", termbox.Event{Ch: 'x'}, true, "^W x is not bound."},
+		{"unbound character", termbox.Event{Ch: 'x'}, false, "^W x is not bound."},
+		{"^H is not bound", termbox.Event{Key: termbox.KeyBackspace}, false, "^W x is not bound."},
+		{"^? is not bound", termbox.Event{Key: termbox.KeyBackspace2}, false, "^W x is not bound."},
+		{"bound key", termbox.Event{Key: termbox.KeyCtrlZ}, true, "Nothing to undo."},
+	}
+
+	for _, tt := range tests {
+		if handled := e.handleKey(tt.event); handled != tt.handled {
+			t.Errorf("%v: handleKey = %v, want %v", tt.name, handled, tt.handled)
+		}
+		if e.StatusMessage != tt.status {
+			t.Errorf("%v: status = %q, want %q", tt.name, e.StatusMessage, tt.status)
+		}
+	}
+}
+
+// TestBackspaceKeys checks that both characters terminals send for Backspace
+// delete a single character, unless the user binds ^H to something else.
+func TestBackspaceKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  map[string]string
+		key   termbox.Key
+		lines []string
+	}{
+		{"^?", nil, termbox.KeyBackspace2, []string{"one tw"}},
+		{"^H", nil, termbox.KeyBackspace, []string{"one tw"}},
+		{"^H bound to deletewordleft", map[string]string{"ctrl+backspace": "deletewordleft"}, termbox.KeyBackspace, []string{"one "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.user, "one two")
+			e.FB().SetCursor(buffer.Position{X: 7, Y: 1})
+
+			e.HandleEvent(termbox.Event{Type: termbox.EventKey, Key: tt.key})
+			if got := e.FB().Strings(); !reflect.DeepEqual(got, tt.lines) {
+				t.Errorf("lines = %q, want %q", got, tt.lines)
+			}
+		})
+	}
+}
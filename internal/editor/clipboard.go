@@ -0,0 +1,72 @@
+package editor
+
+import (
+	"github.com/jonpalmisc/atto/internal/buffer"
+)
+
+// ToggleMark sets the mark at the cursor or unsets it if it is already set.
+func (e *Editor) ToggleMark() {
+	if e.FB().IsMarkSet {
+		e.FB().ClearSelection()
+		e.SetStatusMessage("Mark unset.")
+	} else {
+		e.FB().SetMark()
+		e.SetStatusMessage("Mark set.")
+	}
+}
+
+// Copy copies the selected text to the clipboard.
+func (e *Editor) Copy() {
+	if _, _, ok := e.FB().Selection(); !ok {
+		e.SetStatusMessage("Nothing selected.")
+		return
+	}
+
+	e.Clipboard = e.FB().SelectedText()
+	e.FB().ClearSelection()
+}
+
+// Cut copies the selected text to the clipboard and deletes it from the buffer.
+func (e *Editor) Cut() {
+	if e.FB().IsReadOnly {
+		e.SetStatusMessage("Warning: Read-only buffers cannot be modified.")
+		return
+	}
+
+	if _, _, ok := e.FB().Selection(); !ok {
+		e.SetStatusMessage("Nothing selected.")
+		return
+	}
+
+	e.Clipboard = e.FB().SelectedText()
+	e.FB().DeleteSelection()
+}
+
+// Paste inserts the contents of the clipboard at the cursor, replacing the
+// selected text if there is any.
+func (e *Editor) Paste() {
+	if e.Clipboard == "" {
+		e.SetStatusMessage("Clipboard is empty.")
+		return
+	}
+
+	e.replaceSelection(func(b *buffer.Buffer) { b.InsertText(e.Clipboard) })
+}
+
+// replaceSelection performs an insertion into the focused buffer, deleting the
+// selected text first if there is any. The deletion and the insertion are
+// undone together.
+func (e *Editor) replaceSelection(insert func(b *buffer.Buffer)) {
+	b := e.FB()
+
+	if _, _, ok := b.Selection(); !ok {
+		b.ClearSelection()
+		insert(b)
+		return
+	}
+
+	b.BeginGroup()
+	b.DeleteSelection()
+	insert(b)
+	b.EndGroup()
+}
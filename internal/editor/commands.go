@@ -0,0 +1,340 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonpalmisc/atto/internal/buffer"
+	"github.com/nsf/termbox-go"
+)
+
+// Command is a named editor action which can be run from the command line.
+type Command struct {
+
+	// The command's name, a description of its arguments for the help screen,
+	// and a short description of what it does.
+	Name        string
+	Usage       string
+	Description string
+
+	// Run performs the command with the given arguments. An error is shown to
+	// the user if the arguments are invalid.
+	Run func(e *Editor, args []string) error
+
+	// Whether the text after the command's name is passed to Run as a single
+	// argument instead of being split into words, so that it may contain runs
+	// of spaces, as paths can.
+	RawArgument bool
+
+	// Complete returns the possible values of the last argument, which is
+	// being typed and may be empty. It may be nil if there are none.
+	Complete func(e *Editor, args []string) []string
+}
+
+// commands holds every known command, in the order they were registered.
+var commands []*Command
+
+func init() {
+	RegisterCommand(Command{Name: "open", Usage: "[path]", Description: "Open a new buffer", Run: runOpen, RawArgument: true, Complete: completeFile})
+	RegisterCommand(Command{Name: "save", Usage: "[path]", Description: "Save the current buffer", Run: runSave, RawArgument: true, Complete: completeFile})
+	RegisterCommand(Command{Name: "close", Description: "Close the current buffer", Run: runClose})
+	RegisterCommand(Command{Name: "reload", Description: "Reload the current buffer from its file", Run: runReload})
+	RegisterCommand(Command{Name: "lineending", Usage: "[lf|crlf]", Description: "Convert the line endings between LF and CRLF", Run: runLineEnding, Complete: completeLineEnding})
+	RegisterCommand(Command{Name: "next", Description: "Go to the next buffer", Run: runNext})
+	RegisterCommand(Command{Name: "prev", Description: "Go to the previous buffer", Run: runPrev})
+	RegisterCommand(Command{Name: "files", Description: "Pick a file to open from the working directory", Run: simpleCommand((*Editor).FindFile)})
+	RegisterCommand(Command{Name: "buffers", Description: "Pick a buffer to switch to from a list", Run: simpleCommand((*Editor).SwitchBuffer)})
+	RegisterCommand(Command{Name: "split", Description: "Split the current pane in two, one above the other", Run: simpleCommand(func(e *Editor) { e.SplitPane(false) })})
+	RegisterCommand(Command{Name: "vsplit", Description: "Split the current pane in two, side by side", Run: simpleCommand(func(e *Editor) { e.SplitPane(true) })})
+	RegisterCommand(Command{Name: "closepane", Description: "Close the current pane", Run: simpleCommand((*Editor).ClosePane)})
+	RegisterCommand(Command{Name: "nextpane", Description: "Go to the next pane", Run: simpleCommand(func(e *Editor) { e.CyclePane(false) })})
+	RegisterCommand(Command{Name: "prevpane", Description: "Go to the previous pane", Run: simpleCommand(func(e *Editor) { e.CyclePane(true) })})
+	RegisterCommand(Command{Name: "goto", Usage: "[line]", Description: "Jump to a specific line", Run: runGoto})
+	RegisterCommand(Command{Name: "linestart", Description: "Jump to the beginning of the line", Run: simpleCommand(func(e *Editor) { e.MoveCursorSelecting(CursorMoveLineStart, false) })})
+	RegisterCommand(Command{Name: "lineend", Description: "Jump to the end of the line", Run: simpleCommand(func(e *Editor) { e.MoveCursorSelecting(CursorMoveLineEnd, false) })})
+	RegisterCommand(Command{Name: "wordleft", Description: "Jump to the start of the previous word", Run: simpleCommand(func(e *Editor) { e.MoveCursorSelecting(CursorMoveWordLeft, false) })})
+	RegisterCommand(Command{Name: "wordright", Description: "Jump to the end of the next word", Run: simpleCommand(func(e *Editor) { e.MoveCursorSelecting(CursorMoveWordRight, false) })})
+	RegisterCommand(Command{Name: "paragraphup", Description: "Jump to the blank line before the paragraph", Run: simpleCommand(func(e *Editor) { e.MoveCursorSelecting(CursorMoveParagraphUp, false) })})
+	RegisterCommand(Command{Name: "paragraphdown", Description: "Jump to the blank line after the paragraph", Run: simpleCommand(func(e *Editor) { e.MoveCursorSelecting(CursorMoveParagraphDown, false) })})
+	RegisterCommand(Command{Name: "bufferstart", Description: "Jump to the beginning of the buffer", Run: simpleCommand(func(e *Editor) { e.MoveCursorSelecting(CursorMoveBufferStart, false) })})
+	RegisterCommand(Command{Name: "bufferend", Description: "Jump to the end of the buffer", Run: simpleCommand(func(e *Editor) { e.MoveCursorSelecting(CursorMoveBufferEnd, false) })})
+	RegisterCommand(Command{Name: "matchbracket", Description: "Jump to the matching bracket", Run: simpleCommand(func(e *Editor) { e.MoveCursorSelecting(CursorMoveMatchingBracket, false) })})
+	RegisterCommand(Command{Name: "deletewordleft", Description: "Delete the word before the cursor", Run: simpleCommand(func(e *Editor) { e.DeleteWord(false) })})
+	RegisterCommand(Command{Name: "deletewordright", Description: "Delete the word after the cursor", Run: simpleCommand(func(e *Editor) { e.DeleteWord(true) })})
+	RegisterCommand(Command{Name: "reindent", Description: "Indent the selected lines, or the whole buffer, again", Run: simpleCommand((*Editor).Reindent)})
+	RegisterCommand(Command{Name: "find", Description: "Find text in the current buffer", Run: simpleCommand((*Editor).Find)})
+	RegisterCommand(Command{Name: "findnext", Description: "Go to the next match", Run: simpleCommand(func(e *Editor) { e.FindNext(false) })})
+	RegisterCommand(Command{Name: "findprev", Description: "Go to the previous match", Run: simpleCommand(func(e *Editor) { e.FindNext(true) })})
+	RegisterCommand(Command{Name: "replace", Description: "Find and replace text using a regular expression", Run: simpleCommand((*Editor).Replace)})
+	RegisterCommand(Command{Name: "mark", Description: "Set or unset the mark to start selecting text", Run: simpleCommand((*Editor).ToggleMark)})
+	RegisterCommand(Command{Name: "deselect", Description: "Clear the selection", Run: simpleCommand(func(e *Editor) { e.FB().ClearSelection() })})
+	RegisterCommand(Command{Name: "copy", Description: "Copy the selected text", Run: simpleCommand((*Editor).Copy)})
+	RegisterCommand(Command{Name: "cut", Description: "Cut the selected text", Run: simpleCommand((*Editor).Cut)})
+	RegisterCommand(Command{Name: "paste", Description: "Paste the copied text", Run: simpleCommand((*Editor).Paste)})
+	RegisterCommand(Command{Name: "backspace", Description: "Delete the selection or the character before the cursor", Run: simpleCommand((*Editor).Backspace)})
+	RegisterCommand(Command{Name: "undo", Description: "Undo the last edit", Run: simpleCommand((*Editor).Undo)})
+	RegisterCommand(Command{Name: "redo", Description: "Redo the last undone edit", Run: simpleCommand((*Editor).Redo)})
+	RegisterCommand(Command{Name: "set", Usage: "<option> [value]", Description: "Change or show an option for this session", Run: runSet, Complete: completeSet})
+	RegisterCommand(Command{Name: "commandline", Description: "Run a command by name", Run: simpleCommand((*Editor).CommandLine)})
+	RegisterCommand(Command{Name: "help", Description: "Show this help screen", Run: simpleCommand((*Editor).ShowHelp)})
+}
+
+// RegisterCommand adds a command to the registry. A command with the same name
+// as one already registered replaces it.
+func RegisterCommand(c Command) {
+	for i, r := range commands {
+		if r.Name == c.Name {
+			commands[i] = &c
+			return
+		}
+	}
+
+	commands = append(commands, &c)
+}
+
+// FindCommand returns the command with the given name, or nil if there is no
+// such command.
+func FindCommand(name string) *Command {
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// commandNames returns the names of every command, sorted alphabetically.
+func commandNames() []string {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.Name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// commandHelp returns the section of the help screen which lists every command.
+func commandHelp() []string {
+	var lines []string
+	for _, c := range commands {
+		usage := strings.TrimSpace(c.Name + " " + c.Usage)
+		lines = append(lines, fmt.Sprintf("    %-24v%v", usage, c.Description))
+	}
+
+	return append(lines, "")
+}
+
+// simpleCommand turns an editor action which takes no arguments into a
+// command's Run function.
+func simpleCommand(action func(e *Editor)) func(e *Editor, args []string) error {
+	return func(e *Editor, args []string) error {
+		if len(args) > 0 {
+			return errors.New("too many arguments")
+		}
+
+		action(e)
+		return nil
+	}
+}
+
+// RunCommand parses a command line and runs the command it names.
+func (e *Editor) RunCommand(line string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+
+	c := FindCommand(fields[0])
+	if c == nil {
+		e.SetStatusMessage("Error: Unknown command \"%v\".", fields[0])
+		return
+	}
+
+	args := fields[1:]
+	if c.RawArgument {
+		args = nil
+		if arg := strings.TrimSpace(strings.TrimSpace(line)[len(fields[0]):]); arg != "" {
+			args = []string{arg}
+		}
+	}
+
+	if err := c.Run(e, args); err != nil {
+		usage := strings.TrimSpace(c.Name + " " + c.Usage)
+		e.SetStatusMessage("Error: %v. (Usage: %v)", err, usage)
+	}
+}
+
+// CommandLine prompts the user for a command and runs it. Command names and
+// their arguments are completed with Tab.
+func (e *Editor) CommandLine() {
+	c := completer{candidates: e.commandCandidates}
+
+	line, err := e.AskIncremental("Command: ", "", nil, func(event termbox.Event) bool {
+		if event.Key == termbox.KeyTab {
+			c.complete(e)
+			return true
+		}
+
+		return false
+	})
+	if err != nil {
+		e.SetStatusMessage("User cancelled operation.")
+		return
+	}
+
+	e.RunCommand(line)
+}
+
+// commandCandidates returns the completions for the word being typed into the
+// command line, which is either a command's name or one of its arguments.
+func (e *Editor) commandCandidates(line string) (int, []string) {
+	start := strings.LastIndexAny(line, " \t") + 1
+	fields := strings.Fields(line[:start])
+	word := line[start:]
+
+	if len(fields) == 0 {
+		return start, matching(commandNames(), word, " ")
+	}
+
+	c := FindCommand(fields[0])
+	if c == nil || c.Complete == nil {
+		return start, nil
+	}
+
+	// The whole argument is completed at once if it may contain spaces.
+	if c.RawArgument {
+		name := strings.Index(line, fields[0]) + len(fields[0])
+		start = len(line) - len(strings.TrimLeft(line[name:], " \t"))
+		word = line[start:]
+		return start, matching(c.Complete(e, []string{word}), word, " ")
+	}
+
+	return start, matching(c.Complete(e, append(fields[1:], word)), word, " ")
+}
+
+func runOpen(e *Editor, args []string) error {
+	if len(args) == 0 {
+		e.Open()
+	} else {
+		e.openBuffer(args[0])
+	}
+
+	return nil
+}
+
+func runSave(e *Editor, args []string) error {
+	if len(args) == 0 {
+		e.Save()
+	} else {
+		e.SaveAs(args[0])
+	}
+
+	return nil
+}
+
+func runClose(e *Editor, args []string) error {
+	if len(args) > 0 {
+		return errors.New("too many arguments")
+	}
+
+	e.Close(e.FocusIndex)
+	return nil
+}
+
+func runReload(e *Editor, args []string) error {
+	if len(args) > 0 {
+		return errors.New("too many arguments")
+	} else if e.FB().IsReadOnly {
+		return errors.New("read-only buffers cannot be reloaded")
+	}
+
+	if e.FB().IsDirty && e.AskBool("Discard unsaved changes? [Y/N]: ") != BoolAnswerYes {
+		e.SetStatusMessage("Reload cancelled.")
+		return nil
+	}
+
+	if err := e.FB().Reload(); err != nil {
+		return err
+	}
+
+	e.SetStatusMessage("Reloaded %v.", e.FB().FileName())
+	return nil
+}
+
+func runNext(e *Editor, args []string) error {
+	if len(args) > 0 {
+		return errors.New("too many arguments")
+	}
+
+	if e.FocusIndex+1 < e.BufferCount() {
+		e.focusBuffer(e.FocusIndex + 1)
+	}
+
+	return nil
+}
+
+func runPrev(e *Editor, args []string) error {
+	if len(args) > 0 {
+		return errors.New("too many arguments")
+	}
+
+	if e.FocusIndex > 0 {
+		e.focusBuffer(e.FocusIndex - 1)
+	}
+
+	return nil
+}
+
+func runGoto(e *Editor, args []string) error {
+	switch len(args) {
+	case 0:
+		e.JumpToLine()
+	case 1:
+		i, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid line number \"%v\"", args[0])
+		}
+
+		e.GoToLine(i)
+	default:
+		return errors.New("too many arguments")
+	}
+
+	return nil
+}
+
+func runLineEnding(e *Editor, args []string) error {
+	if len(args) == 0 {
+		e.ToggleLineEnding()
+		return nil
+	} else if len(args) > 1 {
+		return errors.New("too many arguments")
+	} else if e.FB().IsReadOnly {
+		return errors.New("read-only buffers cannot be modified")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "lf":
+		e.FB().SetLineEnding(buffer.LineEndingLF)
+	case "crlf":
+		e.FB().SetLineEnding(buffer.LineEndingCRLF)
+	default:
+		return fmt.Errorf("unknown line ending \"%v\"", args[0])
+	}
+
+	e.SetStatusMessage("Line endings converted to %v.", e.FB().Format.LineEnding)
+	return nil
+}
+
+func completeLineEnding(e *Editor, args []string) []string {
+	if len(args) == 1 {
+		return []string{"lf", "crlf"}
+	}
+
+	return nil
+}
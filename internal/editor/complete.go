@@ -0,0 +1,161 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nsf/termbox-go"
+)
+
+// completer completes the word being typed into a prompt when Tab is pressed.
+// If several candidates match, the common prefix is completed first, after
+// which pressing Tab again cycles through the candidates.
+type completer struct {
+
+	// candidates returns the index in the answer where the word being
+	// completed starts and the possible replacements for the rest of the
+	// answer starting at that index.
+	candidates func(answer string) (start int, words []string)
+
+	// The answer before the completed word, the candidates being cycled
+	// through, the index of the last candidate used and the answer it gave.
+	base  string
+	words []string
+	index int
+	last  string
+}
+
+// complete completes the answer of the active prompt.
+func (c *completer) complete(e *Editor) {
+	answer := e.PromptAnswer
+
+	// Keep cycling if the answer has not changed since the last completion.
+	if answer == c.last && len(c.words) > 1 {
+		c.index = (c.index + 1) % len(c.words)
+		c.apply(e, c.words[c.index])
+		return
+	}
+
+	start, words := c.candidates(answer)
+	if len(words) == 0 {
+		return
+	}
+
+	c.base, c.words, c.index = answer[:start], words, 0
+
+	// Complete as much as all of the candidates have in common, and only start
+	// cycling once there is nothing more in common to complete.
+	if prefix := commonPrefix(words); len(words) == 1 || len(prefix) > len(answer)-start {
+		c.words = nil
+		c.apply(e, prefix)
+	} else {
+		c.apply(e, words[0])
+	}
+}
+
+// apply replaces the word being completed with a candidate.
+func (c *completer) apply(e *Editor, word string) {
+	e.PromptAnswer = c.base + word
+	e.PromptCursor = len([]rune(e.PromptAnswer))
+	c.last = e.PromptAnswer
+}
+
+// commonPrefix returns the longest prefix shared by every word.
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+
+	return prefix
+}
+
+// matching returns the words which start with a prefix, each followed by a
+// suffix. Folders, which end in a slash, are not followed by the suffix so that
+// their contents can be completed next.
+func matching(words []string, prefix, suffix string) []string {
+	var result []string
+	for _, w := range words {
+		if !strings.HasPrefix(w, prefix) {
+			continue
+		}
+
+		if strings.HasSuffix(w, "/") {
+			result = append(result, w)
+		} else {
+			result = append(result, w+suffix)
+		}
+	}
+
+	return result
+}
+
+// completePath returns the paths which complete a partially typed path, for
+// use as the candidates of a completer. Folders end in a slash, and hidden
+// files are only completed once their leading dot has been typed.
+func completePath(path string) (int, []string) {
+	dir, base := filepath.Split(path)
+
+	folder := dir
+	if folder == "" {
+		folder = "."
+	}
+
+	infos, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return 0, nil
+	}
+
+	var paths []string
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+
+		// Links to folders are completed like folders.
+		if info.Mode()&os.ModeSymlink != 0 {
+			if target, err := os.Stat(filepath.Join(folder, name)); err == nil {
+				info = target
+			}
+		}
+
+		if info.IsDir() {
+			name += "/"
+		}
+
+		paths = append(paths, dir+name)
+	}
+
+	return 0, paths
+}
+
+// askPath asks the user for a path, completing it when Tab is pressed.
+func (e *Editor) askPath(question, answer string) (string, error) {
+	c := completer{candidates: completePath}
+
+	return e.AskIncremental(question, answer, nil, func(event termbox.Event) bool {
+		if event.Key == termbox.KeyTab {
+			c.complete(e)
+			return true
+		}
+
+		return false
+	})
+}
+
+// completeFile completes the path given as the only argument of a command.
+func completeFile(e *Editor, args []string) []string {
+	if len(args) != 1 {
+		return nil
+	}
+
+	_, paths := completePath(args[0])
+	return paths
+}
@@ -27,16 +27,59 @@ const (
 	// CursorMoveLineEnd moves the cursor to the end of the line.
 	CursorMoveLineEnd CursorMove = 5
 
-	// CursorMovePageUp moves the cursor up by the  height of the screen.
+	// CursorMovePageUp moves the cursor up by the height of the pane.
 	CursorMovePageUp CursorMove = 6
 
-	// CursorMovePageDown moves the cursor down by the  height of the screen.
+	// CursorMovePageDown moves the cursor down by the height of the pane.
 	CursorMovePageDown CursorMove = 7
+
+	// CursorMoveWordLeft moves the cursor to the start of the previous word,
+	// or to the end of the previous line if it is at the start of a line.
+	CursorMoveWordLeft CursorMove = 8
+
+	// CursorMoveWordRight moves the cursor to the end of the next word, or to
+	// the start of the next line if it is at the end of a line.
+	CursorMoveWordRight CursorMove = 9
+
+	// CursorMoveParagraphUp moves the cursor to the blank line before the
+	// paragraph above it.
+	CursorMoveParagraphUp CursorMove = 10
+
+	// CursorMoveParagraphDown moves the cursor to the blank line after the
+	// paragraph below it.
+	CursorMoveParagraphDown CursorMove = 11
+
+	// CursorMoveBufferStart moves the cursor to the start of the buffer.
+	CursorMoveBufferStart CursorMove = 12
+
+	// CursorMoveBufferEnd moves the cursor to the end of the buffer.
+	CursorMoveBufferEnd CursorMove = 13
+
+	// CursorMoveMatchingBracket moves the cursor to the bracket which pairs
+	// with the one under or just before it.
+	CursorMoveMatchingBracket CursorMove = 14
 )
 
 // MoveCursor moves the cursor according to the operation provided.
 func (e *Editor) MoveCursor(move CursorMove) {
-	rowLength := len(e.FB().FocusedLine().Text)
+
+	// While soft wrapping, vertical movement moves by rows rather than lines.
+	if width := e.wrapWidth(e.FP()); width > 0 && (move == CursorMoveUp || move == CursorMoveDown) {
+		if move == CursorMoveUp {
+			e.moveRow(e.FP(), -1, width)
+		} else {
+			e.moveRow(e.FP(), 1, width)
+		}
+
+		return
+	}
+
+	rowLength := e.FB().FocusedLine().Length()
+
+	// Vertical movement keeps the cursor in the same display column rather than
+	// at the same index, since lines may contain tabs or wide characters.
+	column := e.FB().FocusedLine().AdjustedX(e.FB().CursorX)
+	y := e.FB().CursorY
 
 	switch move {
 	case CursorMoveUp:
@@ -49,14 +92,14 @@ func (e *Editor) MoveCursor(move CursorMove) {
 		}
 	case CursorMoveLeft:
 		if e.FB().CursorX != 0 {
-			e.FB().CursorX--
+			e.FB().CursorX = e.FB().FocusedLine().PreviousBoundary(e.FB().CursorX)
 		} else if e.FB().CursorY > 1 {
-			e.FB().CursorX = len(e.FB().PreviousLine().Text)
+			e.FB().CursorX = e.FB().PreviousLine().Length()
 			e.FB().CursorY--
 		}
 	case CursorMoveRight:
 		if e.FB().CursorX < rowLength {
-			e.FB().CursorX++
+			e.FB().CursorX = e.FB().FocusedLine().NextBoundary(e.FB().CursorX)
 		} else if e.FB().CursorX == rowLength && e.FB().CursorY != e.FB().Length() {
 			e.FB().CursorX = 0
 			e.FB().CursorY++
@@ -73,22 +116,54 @@ func (e *Editor) MoveCursor(move CursorMove) {
 	case CursorMoveLineEnd:
 		e.FB().CursorX = rowLength
 	case CursorMovePageUp:
-		if e.Height > e.FB().CursorY {
+		if rows := e.FP().Height; rows >= e.FB().CursorY {
 			e.FB().CursorY = 1
 		} else {
-			e.FB().CursorY -= e.Height - 2
+			e.FB().CursorY -= rows
 		}
 	case CursorMovePageDown:
-		e.FB().CursorY += e.Height - 2
-		e.FB().OffsetY += e.Height
+		rows := e.FP().Height
+		e.FB().CursorY += rows
+		e.FP().View.OffsetY += rows
 
 		if e.FB().CursorY > e.FB().Length() {
-			e.FB().CursorY = e.FB().Length() - 1
+			e.FB().CursorY = e.FB().Length()
 		}
+	case CursorMoveWordLeft:
+		e.FB().SetCursor(e.FB().PreviousWordStart())
+	case CursorMoveWordRight:
+		e.FB().SetCursor(e.FB().NextWordEnd())
+	case CursorMoveParagraphUp:
+		e.FB().CursorX, e.FB().CursorY = 0, e.FB().PreviousParagraph()
+	case CursorMoveParagraphDown:
+		e.FB().CursorX, e.FB().CursorY = 0, e.FB().NextParagraph()
+
+		// The last paragraph ends at the end of the buffer.
+		if e.FB().CursorY == e.FB().Length() {
+			e.FB().CursorX = e.FB().FocusedLine().Length()
+		}
+	case CursorMoveBufferStart:
+		e.FB().CursorX, e.FB().CursorY = 0, 1
+	case CursorMoveBufferEnd:
+		e.FB().CursorY = e.FB().Length()
+		e.FB().CursorX = e.FB().FocusedLine().Length()
+	case CursorMoveMatchingBracket:
+		if p, ok := e.FB().MatchingBracket(); ok {
+			e.FB().SetCursor(p)
+		} else {
+			e.SetStatusMessage("No matching bracket.")
+		}
+	}
+
+	// Only moving up or down by lines or pages keeps the cursor in the same
+	// column.
+	vertical := move == CursorMoveUp || move == CursorMoveDown || move == CursorMovePageUp || move == CursorMovePageDown
+	if e.FB().CursorY != y && vertical {
+		e.FB().CursorX = e.FB().FocusedLine().IndexAtColumn(column)
 	}
 
 	// Prevent the user from moving past the end of the line.
-	rowLength = len(e.FB().FocusedLine().Text)
+	rowLength = e.FB().FocusedLine().Length()
 	if e.FB().CursorX > rowLength {
 		e.FB().CursorX = rowLength
 	}
@@ -111,6 +186,12 @@ func (e *Editor) JumpToLine() {
 		return
 	}
 
+	e.GoToLine(i)
+}
+
+// GoToLine moves the cursor to the start of a line, clamping the line number
+// to the lines of the buffer.
+func (e *Editor) GoToLine(i int) {
 	lineCount := e.FB().Length()
 
 	// Check if the target line is out of bounds, then jump to the correct line.
@@ -125,3 +206,15 @@ func (e *Editor) JumpToLine() {
 	// Automatically move the cursor to the start of the new line.
 	e.MoveCursor(CursorMoveLineStart)
 }
+
+// MoveCursorSelecting moves the cursor and updates the selection. The selection
+// is extended if selecting is true or the mark is set, and cleared otherwise.
+func (e *Editor) MoveCursorSelecting(move CursorMove, selecting bool) {
+	if selecting && !e.FB().IsSelecting {
+		e.FB().StartSelection()
+	} else if !selecting && !e.FB().IsMarkSet {
+		e.FB().ClearSelection()
+	}
+
+	e.MoveCursor(move)
+}
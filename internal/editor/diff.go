@@ -0,0 +1,30 @@
+package editor
+
+import (
+	"github.com/jonpalmisc/atto/internal/buffer"
+	"github.com/jonpalmisc/atto/internal/support"
+)
+
+// showDiff shows how two versions of a file differ in a read-only buffer, and
+// asks the user a question with AskChoice while it is shown. The buffer is
+// closed again once the question is answered.
+func (e *Editor) showDiff(name, oldName, newName string, a, b []string, question, choices string) rune {
+	diff := support.Diff(oldName, newName, a, b)
+	if len(diff) == 0 {
+		diff = []string{"No differences."}
+	}
+
+	d := buffer.FromStrings(&e.Config, name+".diff", diff)
+	d.IsReadOnly = true
+
+	focus := e.FocusIndex
+	e.Buffers = append(e.Buffers, d)
+	e.FocusIndex = e.BufferCount() - 1
+
+	defer func() {
+		e.Buffers = e.Buffers[:e.BufferCount()-1]
+		e.FocusIndex = focus
+	}()
+
+	return e.AskChoice(question, choices)
+}
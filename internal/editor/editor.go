@@ -8,6 +8,8 @@ import (
 	"github.com/jonpalmisc/atto/internal/buffer"
 	"github.com/jonpalmisc/atto/internal/config"
 	"github.com/jonpalmisc/atto/internal/support"
+	"github.com/jonpalmisc/atto/internal/syntax"
+	"github.com/jonpalmisc/atto/internal/theme"
 	"github.com/nsf/termbox-go"
 )
 
@@ -15,7 +17,7 @@ import (
 type Editor struct {
 
 	// The editor's buffers and the index of the focused buffer.
-	Buffers    []buffer.Buffer
+	Buffers    []*buffer.Buffer
 	FocusIndex int
 
 	// The editor's height and width measured in rows and columns, respectively.
@@ -26,13 +28,56 @@ type Editor struct {
 	StatusMessage     string
 	StatusMessageTime time.Time
 
-	// The prompt question, answer, and whether it is active or not.
+	// The prompt question, answer, and whether it is active or not. The
+	// prompt's cursor is an index into the answer.
 	PromptQuestion string
 	PromptAnswer   string
+	PromptCursor   int
 	PromptIsActive bool
 
-	// The user's editor configuration.
+	// The answers given to each prompt, oldest first, which are kept across
+	// sessions.
+	history map[string][]string
+
+	// The state of the current search.
+	Search Search
+
+	// The clipboard shared between all buffers, and the text most recently
+	// pasted into the terminal.
+	Clipboard string
+	Pasted    string
+
+	// The user's editor configuration and the styles of their color theme.
 	Config config.Config
+	Theme  theme.Styles
+
+	// The layout of the panes the screen is split into, the focused pane,
+	// which shows the focused buffer, and the separators between panes.
+	layout     *layout
+	pane       *Pane
+	separators [][3]int
+
+	// The areas of the title bar showing the names of the open buffers, and
+	// whether text is being selected by dragging the mouse.
+	tabs       []titleTab
+	isDragging bool
+
+	// The list shown in a popup while the user picks an item from it.
+	picker *picker
+
+	// The active key bindings, any problems found with the user's bindings,
+	// and the tree of bound key sequences. While a sequence is being typed,
+	// the keys typed so far and the node they lead to are kept as well.
+	Bindings        []*Binding
+	BindingProblems []string
+	keymap          *keyNode
+	keyState        *keyNode
+	pendingKeys     []Chord
+
+	// The channel events are polled into and events which were read ahead
+	// while decoding escape sequences.
+	events        chan termbox.Event
+	pendingEvents []termbox.Event
 }
 
 // Create creates a new Editor instance.
@@ -42,6 +87,11 @@ func Create() (editor Editor) {
 		panic(err)
 	}
 
+	termbox.SetInputMode(termbox.InputEsc | termbox.InputMouse)
+	setBracketedPaste(true)
+
+	editor.startPolling()
+
 	// Attempt to load the user's editor configuration.
 	cfg, err := config.Load()
 	if err != nil {
@@ -50,11 +100,26 @@ func Create() (editor Editor) {
 
 	editor.Config = cfg
 
+	// Load the user's syntax definitions, which override the built-in ones,
+	// their color theme and their key bindings.
+	editor.loadSyntaxes()
+	if err := editor.loadTheme(); err != nil {
+		editor.SetStatusMessage("Failed to load theme! (%v)", err)
+	}
+
+	editor.loadBindings()
+	editor.loadHistory()
+
+	if n := len(editor.BindingProblems); n > 0 {
+		editor.SetStatusMessage("Found %v problem(s) with your key bindings. (See help)", n)
+	}
+
 	return editor
 }
 
 // Shutdown tears down the terminal screen and ends the process.
 func (e *Editor) Shutdown() {
+	setBracketedPaste(false)
 	termbox.Close()
 	os.Exit(0)
 }
@@ -62,31 +127,46 @@ func (e *Editor) Shutdown() {
 // Run starts the editor.
 func (e *Editor) Run(args []string) {
 
+	// If the editor panics, write swap files for every buffer so that no edits
+	// are lost, and restore the terminal before crashing.
+	defer func() {
+		if r := recover(); r != nil {
+			e.writeSwapFiles()
+			setBracketedPaste(false)
+			termbox.Close()
+			panic(r)
+		}
+	}()
+
 	// If we have arguments, create a new buffer for each argument.
 	if len(args) != 0 {
 		for _, path := range args {
-			b, err := buffer.Create(&e.Config, path)
-			if err != nil {
-				e.SetStatusMessage("Error: %v", err)
-				continue
-			}
-
-			e.Buffers = append(e.Buffers, b)
+			e.openBuffer(path)
 		}
+
+		e.FocusIndex = 0
 	} else {
 		b, err := buffer.Create(&e.Config, "Untitled")
 		if err != nil {
 			panic(err)
 		}
 
-		e.Buffers = []buffer.Buffer{b}
+		e.Buffers = []*buffer.Buffer{b}
+		e.offerRecovery(b)
 	}
 
 	// Perform the initial draw of the UI.
 	e.Draw()
+	e.startSwapTimer()
 
 	for {
-		e.HandleEvent(termbox.PollEvent())
+
+		// Swap files are written when polling is interrupted by the timer.
+		if event := e.PollEvent(); event.Type == termbox.EventInterrupt {
+			e.writeSwapFiles()
+		} else {
+			e.HandleEvent(event)
+		}
 
 		// If there are no remaining buffers, terminate the program.
 		if e.BufferCount() == 0 {
@@ -104,7 +184,7 @@ func (e *Editor) Run(args []string) {
 
 // FB returns the focused buffer.
 func (e *Editor) FB() *buffer.Buffer {
-	return &e.Buffers[e.FocusIndex]
+	return e.Buffers[e.FocusIndex]
 }
 
 // BufferCount is a shorthand for getting the number of open buffers.
@@ -118,10 +198,59 @@ func (e *Editor) SetStatusMessage(format string, args ...interface{}) {
 	e.StatusMessageTime = time.Now()
 }
 
+// ShowHelp opens the help screen in a new read-only buffer.
 func (e *Editor) ShowHelp() {
-	b := buffer.FromStrings(&e.Config, "Help.txt", support.HelpMessage)
+	b := buffer.FromStrings(&e.Config, "Help.txt", support.HelpMessage(e.shortcutHelp(), commandHelp()))
 	b.IsReadOnly = true
 
 	e.Buffers = append(e.Buffers, b)
 	e.FocusIndex = e.BufferCount() - 1
 }
+
+// loadSyntaxes loads the user's syntax definitions from the syntax folder.
+func (e *Editor) loadSyntaxes() {
+	path, err := config.FolderPath("syntax")
+	if err != nil {
+		e.SetStatusMessage("Failed to load syntax definitions! (%v)", err)
+		return
+	}
+
+	if errs := syntax.LoadFolder(path); len(errs) > 0 {
+		e.SetStatusMessage("Failed to load syntax definitions! (%v)", errs[0])
+	}
+}
+
+// loadTheme loads the user's color theme from the themes folder and sets the
+// output mode it is resolved for. The default theme is used if it fails to
+// load, and the error is returned.
+func (e *Editor) loadTheme() error {
+	t := theme.Default()
+
+	path, err := config.FolderPath("themes")
+	if err == nil {
+		t, err = theme.Load(path, e.Config.Theme)
+	}
+
+	if err == nil {
+		err = e.applyTheme(t)
+	}
+
+	if err != nil {
+		e.applyTheme(theme.Default())
+	}
+
+	return err
+}
+
+// applyTheme sets the output mode for a theme and resolves its styles.
+func (e *Editor) applyTheme(t theme.Theme) error {
+	mode := termbox.SetOutputMode(theme.OutputMode(e.Config.ColorMode, &t))
+
+	styles, err := t.Resolve(mode)
+	if err != nil {
+		return err
+	}
+
+	e.Theme = styles
+	return nil
+}
@@ -0,0 +1,255 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+const (
+
+	// ModShift is set on key events when the Shift key was held. Termbox does
+	// not report it, so it is decoded from escape sequences by the editor.
+	ModShift termbox.Modifier = 1 << 4
+
+	// ModCtrl is set on key events when the Control key was held along with a
+	// key which termbox does not report a Ctrl variant of, such as the arrows.
+	ModCtrl termbox.Modifier = 1 << 5
+)
+
+// EventPaste is the type of the events reported when text is pasted into the
+// terminal. The pasted text is kept in the editor's Pasted field.
+const EventPaste termbox.EventType = 100
+
+// escapeTimeout is how long to wait for the rest of an escape sequence after
+// an Esc key event is received.
+const escapeTimeout = 10 * time.Millisecond
+
+// The sequences terminals send around pasted text in bracketed paste mode, not
+// counting the initial Esc, and how long to wait for the rest of the pasted
+// text before giving up on the closing sequence.
+const (
+	pasteStart   = "[200~"
+	pasteEnd     = "[201~"
+	pasteTimeout = time.Second
+)
+
+// setBracketedPaste turns the terminal's bracketed paste mode on or off. In
+// this mode, pasted text is sent between two escape sequences, which tells it
+// apart from typed text.
+func setBracketedPaste(enabled bool) {
+	if enabled {
+		fmt.Fprint(os.Stdout, "\x1b[?2004h")
+	} else {
+		fmt.Fprint(os.Stdout, "\x1b[?2004l")
+	}
+}
+
+// startPolling starts polling termbox for events in the background.
+func (e *Editor) startPolling() {
+	e.events = make(chan termbox.Event)
+
+	go func() {
+		for {
+			e.events <- termbox.PollEvent()
+		}
+	}()
+}
+
+// nextEvent returns the next event, waiting at most timeout for it to arrive.
+// A timeout of zero waits indefinitely.
+func (e *Editor) nextEvent(timeout time.Duration) (termbox.Event, bool) {
+	if len(e.pendingEvents) > 0 {
+		event := e.pendingEvents[0]
+		e.pendingEvents = e.pendingEvents[1:]
+
+		return event, true
+	}
+
+	if timeout == 0 {
+		return <-e.events, true
+	}
+
+	select {
+	case event := <-e.events:
+		return event, true
+	case <-time.After(timeout):
+		return termbox.Event{}, false
+	}
+}
+
+// PollEvent waits for the next event, decoding escape sequences for modified
+// keys which termbox does not recognize on its own. A key pressed right after
+// Esc is reported as pressed with Alt, since that is how terminals send it.
+// Pasted text is collected and reported as a single EventPaste event.
+func (e *Editor) PollEvent() termbox.Event {
+	event, _ := e.nextEvent(0)
+	if event.Type != termbox.EventKey || event.Key != termbox.KeyEsc {
+		return event
+	}
+
+	next, ok := e.nextEvent(escapeTimeout)
+	if !ok {
+		return event
+	} else if next.Type == termbox.EventKey && next.Ch != '[' {
+		next.Mod |= termbox.ModAlt
+		return next
+	}
+
+	// Collect the rest of the sequence, which arrives as regular characters,
+	// until it is either recognized or cannot be part of a sequence.
+	var consumed []termbox.Event
+	var sequence string
+	for ; ok; next, ok = e.nextEvent(escapeTimeout) {
+		consumed = append(consumed, next)
+		if next.Type != termbox.EventKey || next.Ch == 0 {
+			break
+		}
+
+		sequence += string(next.Ch)
+		if sequence == pasteStart {
+			e.Pasted = e.readPaste()
+			return termbox.Event{Type: EventPaste}
+		} else if decoded, ok := decodeEscapeSequence(sequence); ok {
+			return decoded
+		} else if !isEscapeSequencePrefix(sequence) {
+			break
+		}
+	}
+
+	// The sequence was not recognized, so replay everything after the Esc key.
+	e.pendingEvents = append(consumed, e.pendingEvents...)
+	return event
+}
+
+// readPaste collects the text pasted into the terminal up to the sequence which
+// ends it. Line breaks are converted to newlines.
+func (e *Editor) readPaste() string {
+	var text []rune
+
+	for {
+		event, ok := e.nextEvent(pasteTimeout)
+		if !ok {
+			break
+		} else if event.Type != termbox.EventKey {
+			continue
+		}
+
+		switch {
+		case event.Ch != 0:
+			text = append(text, event.Ch)
+		case event.Key == termbox.KeyEnter:
+			text = append(text, '\r')
+		case event.Key == termbox.KeyCtrlJ:
+			text = append(text, '\n')
+		case event.Key == termbox.KeyTab:
+			text = append(text, '\t')
+		case event.Key == termbox.KeySpace:
+			text = append(text, ' ')
+		case event.Key == termbox.KeyEsc:
+			text = append(text, '\x1b')
+		}
+
+		// Stop at the closing sequence, which is not part of the text.
+		if n := len(text) - len(pasteEnd) - 1; n >= 0 && text[n] == '\x1b' && string(text[n+1:]) == pasteEnd {
+			text = text[:n]
+			break
+		}
+	}
+
+	pasted := strings.Replace(string(text), "\r\n", "\n", -1)
+	pasted = strings.Replace(pasted, "\r", "\n", -1)
+
+	return strings.Replace(pasted, "\x1b", "", -1)
+}
+
+// isEscapeSequencePrefix tells whether a string could be the beginning of a
+// CSI escape sequence, not counting the initial Esc.
+func isEscapeSequencePrefix(s string) bool {
+	if !strings.HasPrefix(s, "[") {
+		return false
+	}
+
+	for _, c := range s[1:] {
+		if (c < '0' || c > '9') && c != ';' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// decodeEscapeSequence decodes a CSI escape sequence for a key, not counting
+// the initial Esc. Sequences for unmodified keys are decoded as well, since
+// some terminals send them even though termbox does not expect them.
+func decodeEscapeSequence(s string) (termbox.Event, bool) {
+	if len(s) < 2 || s[0] != '[' {
+		return termbox.Event{}, false
+	}
+
+	params := strings.Split(s[1:len(s)-1], ";")
+	if len(params) > 2 {
+		return termbox.Event{}, false
+	}
+
+	event := termbox.Event{Type: termbox.EventKey}
+
+	// The second parameter, if present, encodes the modifiers plus one.
+	if len(params) == 2 {
+		mods, err := strconv.Atoi(params[1])
+		if err != nil || mods < 1 {
+			return termbox.Event{}, false
+		}
+
+		mods--
+		if mods&1 != 0 {
+			event.Mod |= ModShift
+		}
+		if mods&2 != 0 {
+			event.Mod |= termbox.ModAlt
+		}
+		if mods&4 != 0 {
+			event.Mod |= ModCtrl
+		}
+	}
+
+	switch s[len(s)-1] {
+	case 'A':
+		event.Key = termbox.KeyArrowUp
+	case 'B':
+		event.Key = termbox.KeyArrowDown
+	case 'C':
+		event.Key = termbox.KeyArrowRight
+	case 'D':
+		event.Key = termbox.KeyArrowLeft
+	case 'H':
+		event.Key = termbox.KeyHome
+	case 'F':
+		event.Key = termbox.KeyEnd
+	case '~':
+		switch params[0] {
+		case "1", "7":
+			event.Key = termbox.KeyHome
+		case "4", "8":
+			event.Key = termbox.KeyEnd
+		case "2":
+			event.Key = termbox.KeyInsert
+		case "3":
+			event.Key = termbox.KeyDelete
+		case "5":
+			event.Key = termbox.KeyPgup
+		case "6":
+			event.Key = termbox.KeyPgdn
+		default:
+			return termbox.Event{}, false
+		}
+	default:
+		return termbox.Event{}, false
+	}
+
+	return event, true
+}
@@ -0,0 +1,133 @@
+package editor
+
+import (
+	"strconv"
+
+	"github.com/jonpalmisc/atto/internal/buffer"
+
+	"github.com/nsf/termbox-go"
+)
+
+const (
+
+	// LineNumbersOff hides the gutter.
+	LineNumbersOff = "off"
+
+	// LineNumbersAbsolute shows the number of each line.
+	LineNumbersAbsolute = "absolute"
+
+	// LineNumbersRelative shows the distance of each line from the cursor,
+	// with the cursor's line showing its own number.
+	LineNumbersRelative = "relative"
+)
+
+// markerWidth is the number of columns at the start of the gutter which are
+// reserved for line markers, such as diagnostics or version control changes.
+const markerWidth = 1
+
+// GutterWidth returns the width of the gutter to the left of a buffer, which
+// grows with the number of digits of the last line number.
+func (e *Editor) GutterWidth(b *buffer.Buffer) int {
+	if !e.showsLineNumbers() {
+
+		// Soft wrapping needs room to mark the rows lines continue on.
+		if e.Config.SoftWrap {
+			return markerWidth + 1
+		}
+
+		return 0
+	}
+
+	// Leave room for the markers, the digits and a space before the text.
+	return markerWidth + len(strconv.Itoa(b.Length())) + 1
+}
+
+// TextWidth returns the width of the area a pane's buffer text is drawn in.
+func (e *Editor) TextWidth(p *Pane) int {
+	if width := p.Width - e.GutterWidth(p.Buffer); width > 0 {
+		return width
+	}
+
+	return 0
+}
+
+// showsLineNumbers tells whether line numbers are shown in the gutter.
+func (e *Editor) showsLineNumbers() bool {
+	return e.Config.LineNumbers == LineNumbersAbsolute || e.Config.LineNumbers == LineNumbersRelative
+}
+
+// lineMarker returns the marker to show in the gutter for the line at index
+// i. No markers are defined yet, so the marker column is always blank.
+func (e *Editor) lineMarker(b *buffer.Buffer, i int) rune {
+	return ' '
+}
+
+// lineNumber returns the number to show in a pane's gutter for the line at
+// index i.
+func (e *Editor) lineNumber(p *Pane, i int) int {
+	cursor := p.View.CursorY - 1
+	if e.Config.LineNumbers != LineNumbersRelative || i == cursor {
+		return i + 1
+	} else if i < cursor {
+		return cursor - i
+	}
+
+	return i - cursor
+}
+
+// DrawGutter draws a pane's gutter for the line at index i on screen row y.
+func (e *Editor) DrawGutter(p *Pane, i, y int) {
+	b := p.Buffer
+
+	width := e.GutterWidth(b)
+	if width == 0 {
+		return
+	} else if width > p.Width {
+		width = p.Width
+	}
+
+	style := e.Theme.LineNumber
+	if i == p.View.CursorY-1 {
+		style = e.Theme.CurrentLineNumber
+	}
+
+	fg, bg := style.Apply(termbox.ColorDefault, termbox.ColorDefault)
+	for x := 0; x < width; x++ {
+		termbox.SetCell(p.X+x, y, ' ', fg, bg)
+	}
+
+	termbox.SetCell(p.X, y, e.lineMarker(b, i), fg, bg)
+
+	// Right-align the number against the space before the text, unless the
+	// pane is too narrow to fit it.
+	number := strconv.Itoa(e.lineNumber(p, i))
+	if x := width - 1 - len(number); x >= 0 && e.showsLineNumbers() {
+		drawText([]rune(number), p.X+x, y, fg, bg)
+	}
+}
+
+// drawWrapGutter draws a pane's gutter on screen row y, which shows a row the
+// line at index i continues on while soft wrapping. The row is marked where
+// the line number would end.
+func (e *Editor) drawWrapGutter(p *Pane, i, y int) {
+	b := p.Buffer
+
+	width := e.GutterWidth(b)
+	if width > p.Width {
+		width = p.Width
+	}
+
+	style := e.Theme.LineNumber
+	if i == p.View.CursorY-1 {
+		style = e.Theme.CurrentLineNumber
+	}
+
+	fg, bg := style.Apply(termbox.ColorDefault, termbox.ColorDefault)
+	for x := 0; x < width; x++ {
+		termbox.SetCell(p.X+x, y, ' ', fg, bg)
+	}
+
+	if width >= 2 {
+		termbox.SetCell(p.X+width-2, y, wrapMarker, fg, bg)
+	}
+}
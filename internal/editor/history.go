@@ -0,0 +1,84 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/jonpalmisc/atto/internal/config"
+	"gopkg.in/yaml.v2"
+)
+
+// historyLimit is the number of answers remembered for each prompt.
+const historyLimit = 100
+
+// historyKey returns the name a prompt's answers are remembered under, which is
+// its question without the colon.
+func historyKey(question string) string {
+	return strings.TrimRight(question, ": ")
+}
+
+// loadHistory loads the answers given to prompts in earlier sessions from the
+// history file.
+func (e *Editor) loadHistory() {
+	e.history = make(map[string][]string)
+
+	path, err := config.FolderPath("history")
+	if err != nil {
+		e.SetStatusMessage("Failed to load prompt history! (%v)", err)
+		return
+	}
+
+	yml, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	} else if err == nil {
+		err = yaml.Unmarshal(yml, &e.history)
+	}
+
+	if err != nil {
+		e.SetStatusMessage("Failed to load prompt history! (%v)", err)
+	}
+}
+
+// remember adds an answer to the end of a prompt's history, removing earlier
+// copies of it, and saves the history file.
+func (e *Editor) remember(question, answer string) {
+	if answer == "" {
+		return
+	}
+
+	key := historyKey(question)
+	answers := []string{}
+	for _, a := range e.history[key] {
+		if a != answer {
+			answers = append(answers, a)
+		}
+	}
+
+	answers = append(answers, answer)
+	if len(answers) > historyLimit {
+		answers = answers[len(answers)-historyLimit:]
+	}
+
+	e.history[key] = answers
+
+	if err := e.saveHistory(); err != nil {
+		e.SetStatusMessage("Failed to save prompt history! (%v)", err)
+	}
+}
+
+// saveHistory writes the history of every prompt to the history file.
+func (e *Editor) saveHistory() error {
+	path, err := config.FolderPath("history")
+	if err != nil {
+		return err
+	}
+
+	yml, err := yaml.Marshal(e.history)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, yml, 0600)
+}
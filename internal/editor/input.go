@@ -2,119 +2,214 @@ package editor
 
 import (
 	"errors"
+	"strings"
 	"unicode"
 
 	"github.com/jonpalmisc/atto/internal/buffer"
+	"github.com/jonpalmisc/atto/internal/support"
 	"github.com/nsf/termbox-go"
 )
 
 // HandleEvent executes the appropriate code in response to an event.
 func (e *Editor) HandleEvent(event termbox.Event) {
 	switch event.Type {
+	case termbox.EventMouse:
+		e.HandleMouse(event)
+	case EventPaste:
+
+		// Pasted text is inserted as is, without indenting new lines, and is
+		// undone in a single step.
+		e.FP().isScrolled = false
+		e.replaceSelection(func(b *buffer.Buffer) { b.InsertText(e.Pasted) })
 	case termbox.EventKey:
+
+		// Using the keyboard brings the cursor back into view after scrolling
+		// with the mouse wheel.
+		e.FP().isScrolled = false
+
+		// Bound keys take precedence over the built-in ones.
+		if e.handleKey(event) {
+			return
+		}
+
+		// Characters are reported with a key value of zero, which termbox uses
+		// for Ctrl-Space as well, so they must be handled first. Unbound
+		// characters typed with Alt are ignored.
+		if event.Ch != 0 {
+			if !buffer.IsInsertable(event.Ch) || event.Mod&termbox.ModAlt != 0 {
+				return
+			}
+
+			// Typing a rune which opens a pair wraps the selection in the pair
+			// rather than replacing it.
+			if !e.FB().WrapSelection(event.Ch) {
+				e.replaceSelection(func(b *buffer.Buffer) { b.InsertRune(event.Ch) })
+			}
+			return
+		}
+
+		wordwise := event.Mod&(ModCtrl|termbox.ModAlt) != 0
+
 		switch event.Key {
 
-		// Handle cursor movement keys.
+		// Handle cursor movement keys. Holding shift extends the selection,
+		// and holding Ctrl or Alt moves by words and paragraphs with the arrows
+		// or to either end of the buffer with Home and End.
 		case termbox.KeyArrowUp:
-			e.MoveCursor(CursorMoveUp)
+			if wordwise {
+				e.MoveCursorSelecting(CursorMoveParagraphUp, event.Mod&ModShift != 0)
+			} else {
+				e.MoveCursorSelecting(CursorMoveUp, event.Mod&ModShift != 0)
+			}
 		case termbox.KeyArrowDown:
-			e.MoveCursor(CursorMoveDown)
+			if wordwise {
+				e.MoveCursorSelecting(CursorMoveParagraphDown, event.Mod&ModShift != 0)
+			} else {
+				e.MoveCursorSelecting(CursorMoveDown, event.Mod&ModShift != 0)
+			}
 		case termbox.KeyArrowLeft:
-			e.MoveCursor(CursorMoveLeft)
+			if wordwise {
+				e.MoveCursorSelecting(CursorMoveWordLeft, event.Mod&ModShift != 0)
+			} else {
+				e.MoveCursorSelecting(CursorMoveLeft, event.Mod&ModShift != 0)
+			}
 		case termbox.KeyArrowRight:
-			e.MoveCursor(CursorMoveRight)
+			if wordwise {
+				e.MoveCursorSelecting(CursorMoveWordRight, event.Mod&ModShift != 0)
+			} else {
+				e.MoveCursorSelecting(CursorMoveRight, event.Mod&ModShift != 0)
+			}
 		case termbox.KeyPgup:
-			e.MoveCursor(CursorMovePageUp)
+			e.MoveCursorSelecting(CursorMovePageUp, event.Mod&ModShift != 0)
 		case termbox.KeyPgdn:
-			e.MoveCursor(CursorMovePageDown)
-		case termbox.KeyCtrlA:
-			e.MoveCursor(CursorMoveLineStart)
-		case termbox.KeyCtrlE:
-			e.MoveCursor(CursorMoveLineEnd)
-
-		case termbox.KeyCtrlH:
-			e.ShowHelp()
-
-		// Handle buffer operation keys.
-		case termbox.KeyCtrlR:
-			e.Open()
-		case termbox.KeyCtrlO:
-			e.Save()
-		case termbox.KeyCtrlX:
-			e.Close(e.FocusIndex)
-		case termbox.KeyCtrlP:
-			if e.FocusIndex+1 < e.BufferCount() {
-				e.FocusIndex++
+			e.MoveCursorSelecting(CursorMovePageDown, event.Mod&ModShift != 0)
+		case termbox.KeyHome:
+			if wordwise {
+				e.MoveCursorSelecting(CursorMoveBufferStart, event.Mod&ModShift != 0)
+			} else {
+				e.MoveCursorSelecting(CursorMoveLineStart, event.Mod&ModShift != 0)
 			}
-		case termbox.KeyCtrlL:
-			if e.FocusIndex > 0 {
-				e.FocusIndex--
+		case termbox.KeyEnd:
+			if wordwise {
+				e.MoveCursorSelecting(CursorMoveBufferEnd, event.Mod&ModShift != 0)
+			} else {
+				e.MoveCursorSelecting(CursorMoveLineEnd, event.Mod&ModShift != 0)
 			}
 
-		case termbox.KeyCtrlJ:
-			e.JumpToLine()
-
-		// Handle regular input keys.
-		case termbox.KeyBackspace2:
-			e.FB().DeleteRune()
+		// Handle regular input keys. Terminals send either ^? or ^H for
+		// Backspace.
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			e.Backspace()
 		case termbox.KeyEnter:
-			e.FB().BreakLine()
+			e.replaceSelection(func(b *buffer.Buffer) { b.BreakLine() })
 		case termbox.KeyTab:
-			e.FB().InsertRune('\t')
+			e.replaceSelection(func(b *buffer.Buffer) { b.InsertRune('\t') })
 		case termbox.KeySpace:
-			e.FB().InsertRune(' ')
-		default:
-			e.FB().InsertRune(event.Ch)
+			e.replaceSelection(func(b *buffer.Buffer) { b.InsertRune(' ') })
 		}
 	}
 }
 
+// Backspace deletes the selected text, or the character before the cursor if
+// nothing is selected.
+func (e *Editor) Backspace() {
+	if !e.FB().DeleteSelection() {
+		e.FB().DeleteRune()
+	}
+}
+
+// DeleteWord deletes the selected text, or the word before or after the cursor
+// if nothing is selected.
+func (e *Editor) DeleteWord(forward bool) {
+	if e.FB().DeleteSelection() {
+		return
+	}
+
+	if forward {
+		e.FB().DeleteNextWord()
+	} else {
+		e.FB().DeletePreviousWord()
+	}
+}
+
+// Reindent indents the selected lines again according to the buffer's syntax,
+// or every line of the buffer if nothing is selected.
+func (e *Editor) Reindent() {
+	b := e.FB()
+	if b.IsReadOnly {
+		e.SetStatusMessage("Warning: Read-only buffers cannot be modified.")
+		return
+	} else if b.Syntax == nil {
+		e.SetStatusMessage("Error: No syntax is known for %v.", b.FileName())
+		return
+	}
+
+	first, last := 1, b.Length()
+	if start, end, ok := b.Selection(); ok {
+		first, last = start.Y, end.Y
+
+		// A selection ending at the start of a line does not include it.
+		if end.X == 0 && end.Y > start.Y {
+			last--
+		}
+	}
+
+	b.Reindent(first, last)
+	b.ClampView(b.View)
+
+	e.SetStatusMessage("Reindented %v line(s).", last-first+1)
+}
+
 // InsertPromptRune inserts a rune into the current prompt answer.
 func (e *Editor) InsertPromptRune(c rune) {
 	if buffer.IsInsertable(c) {
-		i := e.FB().CursorX - len(e.PromptQuestion)
+		answer, i := []rune(e.PromptAnswer), e.PromptCursor
+
+		e.PromptAnswer = string(answer[:i]) + string(c) + string(answer[i:])
+		e.PromptCursor++
+	}
+}
+
+// InsertPromptText inserts the first line of a block of text into the current
+// prompt answer.
+func (e *Editor) InsertPromptText(text string) {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
 
-		e.PromptAnswer = e.PromptAnswer[:i] + string(c) + e.PromptAnswer[i:]
-		e.FB().CursorX++
+	for _, c := range text {
+		e.InsertPromptRune(c)
 	}
 }
 
 // DeletePromptRune deletes a rune from the current prompt answer.
 func (e *Editor) DeletePromptRune() {
-	x := e.FB().CursorX - len(e.PromptQuestion) - 1
+	answer, x := []rune(e.PromptAnswer), e.PromptCursor-1
 
-	if x >= 0 && x < len(e.PromptAnswer) {
-		e.PromptAnswer = e.PromptAnswer[:x] + e.PromptAnswer[x+1:]
-		e.FB().CursorX--
+	if x >= 0 && x < len(answer) {
+		e.PromptAnswer = string(answer[:x]) + string(answer[x+1:])
+		e.PromptCursor--
 	}
 }
 
 // MovePromptCursor moves the cursor inside of the current prompt.
 func (e *Editor) MovePromptCursor(move CursorMove) {
-	x := e.FB().CursorX - len(e.PromptQuestion)
-
 	switch move {
 	case CursorMoveLeft:
-		if x != 0 {
-			e.FB().CursorX--
+		if e.PromptCursor != 0 {
+			e.PromptCursor--
 		}
 	case CursorMoveRight:
-		if x < len(e.PromptAnswer) {
-			e.FB().CursorX++
+		if e.PromptCursor < len([]rune(e.PromptAnswer)) {
+			e.PromptCursor++
 		}
 	}
 }
 
-// restoreCursor just sets the cursor position but this function is meant to be
-// called using defer inside prompt-related methods.
-func (e *Editor) restoreCursor(x, y int) {
-	e.FB().CursorX, e.FB().CursorY = x, y
-}
-
 // activatePrompt opens the prompt and moves the cursor to the prompt.
 func (e *Editor) activatePrompt(question, answer string) {
 	e.PromptQuestion, e.PromptAnswer, e.PromptIsActive = question, answer, true
-	e.FB().CursorY, e.FB().CursorX = e.Height, len(question)+len(answer)
+	e.PromptCursor = len([]rune(answer))
 }
 
 // closePrompt is just syntactic sugar for setting PromptIsActive to false, but
@@ -126,38 +221,141 @@ func (e *Editor) closePrompt() {
 // Ask prompts the user to answer a question and assumes control over all input
 // until the question is answered or the request is cancelled.
 func (e *Editor) Ask(question, answer string) (string, error) {
+	return e.AskIncremental(question, answer, nil, nil)
+}
 
-	// Restore the cursor position and close the prompt when the function exits.
-	defer e.restoreCursor(e.FB().CursorX, e.FB().CursorY)
+// AskIncremental prompts the user like Ask, but calls onChange every time the
+// answer changes and offers every key event to onKey before handling it. If
+// onKey returns true, the event is considered handled. Either function may be
+// nil. Earlier answers to the same question can be recalled with the up and
+// down arrows.
+func (e *Editor) AskIncremental(question, answer string, onChange func(answer string), onKey func(event termbox.Event) bool) (string, error) {
+
+	// Close the prompt when the function exits.
 	defer e.closePrompt()
 
 	// Activate the prompt and poll events until the user responds or cancels.
 	e.activatePrompt(question, answer)
+	if onChange != nil {
+		onChange(e.PromptAnswer)
+	}
+
+	// The position in the prompt's history, and the answer being typed before
+	// the history was entered.
+	history := e.history[historyKey(question)]
+	index, draft := len(history), ""
+
 	for {
 		e.Draw()
 
-		switch event := termbox.PollEvent(); event.Type {
-		case termbox.EventKey:
-			switch event.Key {
-			case termbox.KeyCtrlC:
-				return "", errors.New("user cancelled")
-			case termbox.KeyEnter:
-				return e.PromptAnswer, nil
-			case termbox.KeyArrowLeft:
-				e.MovePromptCursor(CursorMoveLeft)
-			case termbox.KeyArrowRight:
-				e.MovePromptCursor(CursorMoveRight)
-			case termbox.KeyBackspace2:
-				e.DeletePromptRune()
-			case termbox.KeySpace:
-				e.InsertPromptRune(' ')
-			default:
-				e.InsertPromptRune(event.Ch)
+		event := e.PollEvent()
+		previous := e.PromptAnswer
+
+		if event.Type == EventPaste {
+			e.InsertPromptText(e.Pasted)
+		}
+
+		if event.Type != termbox.EventKey {
+			if onChange != nil && e.PromptAnswer != previous {
+				onChange(e.PromptAnswer)
+			}
+			continue
+		}
+
+		if onKey != nil && onKey(event) {
+			continue
+		}
+
+		switch event.Key {
+		case termbox.KeyCtrlC:
+			return "", errors.New("user cancelled")
+		case termbox.KeyEnter:
+			e.remember(question, e.PromptAnswer)
+			return e.PromptAnswer, nil
+		case termbox.KeyArrowUp:
+			if index > 0 {
+				if index == len(history) {
+					draft = e.PromptAnswer
+				}
+
+				index--
+				e.setPromptAnswer(history[index])
 			}
+		case termbox.KeyArrowDown:
+			if index < len(history)-1 {
+				index++
+				e.setPromptAnswer(history[index])
+			} else if index == len(history)-1 {
+				index++
+				e.setPromptAnswer(draft)
+			}
+		default:
+			e.EditPrompt(event)
 		}
+
+		if onChange != nil && e.PromptAnswer != previous {
+			onChange(e.PromptAnswer)
+		}
+	}
+}
+
+// EditPrompt edits the answer of the active prompt in response to a key event.
+// Besides typing, the cursor can be moved by words and to either end of the
+// answer, and the answer can be cut back by words or to either end, using the
+// usual shell shortcuts.
+func (e *Editor) EditPrompt(event termbox.Event) {
+	answer := []rune(e.PromptAnswer)
+
+	// Words are skipped with the arrows while holding Ctrl or Alt, or with
+	// Alt-B and Alt-F.
+	alt := event.Mod&termbox.ModAlt != 0
+	wordwise := alt || event.Mod&ModCtrl != 0
+
+	switch key := event.Key; {
+	case key == termbox.KeyArrowLeft && wordwise, alt && event.Ch == 'b':
+		e.PromptCursor = support.PreviousWordStart(answer, e.PromptCursor)
+	case key == termbox.KeyArrowRight && wordwise, alt && event.Ch == 'f':
+		e.PromptCursor = support.NextWordEnd(answer, e.PromptCursor)
+	case alt && event.Ch != 0:
+		// Other characters typed with Alt are not inserted.
+	case key == termbox.KeyArrowLeft:
+		e.MovePromptCursor(CursorMoveLeft)
+	case key == termbox.KeyArrowRight:
+		e.MovePromptCursor(CursorMoveRight)
+	case key == termbox.KeyHome, key == termbox.KeyCtrlA:
+		e.PromptCursor = 0
+	case key == termbox.KeyEnd, key == termbox.KeyCtrlE:
+		e.PromptCursor = len(answer)
+	case key == termbox.KeyBackspace, key == termbox.KeyBackspace2:
+		e.DeletePromptRune()
+	case key == termbox.KeyDelete:
+		if e.PromptCursor < len(answer) {
+			e.PromptCursor++
+			e.DeletePromptRune()
+		}
+	case key == termbox.KeyCtrlW:
+		start := support.PreviousWordStart(answer, e.PromptCursor)
+		e.PromptAnswer = string(answer[:start]) + string(answer[e.PromptCursor:])
+		e.PromptCursor = start
+	case key == termbox.KeyCtrlU:
+		e.PromptAnswer = string(answer[e.PromptCursor:])
+		e.PromptCursor = 0
+	case key == termbox.KeyCtrlK:
+		e.PromptAnswer = string(answer[:e.PromptCursor])
+	case key == termbox.KeySpace:
+		e.InsertPromptRune(' ')
+	default:
+		e.InsertPromptRune(event.Ch)
 	}
 }
 
+// setPromptAnswer replaces the answer of the active prompt, moving the cursor
+// to its end.
+func (e *Editor) setPromptAnswer(answer string) {
+	e.PromptAnswer = answer
+	e.PromptCursor = len([]rune(answer))
+}
+
 // BoolAnswer represents a boolean answer choice.
 type BoolAnswer int
 
@@ -171,13 +369,31 @@ const (
 
 	// BoolAnswerYes represents a "Yes" answer.
 	BoolAnswerYes BoolAnswer = 1
+
+	// BoolAnswerAll represents a "Yes to all" answer.
+	BoolAnswerAll BoolAnswer = 2
+
+	// BoolAnswerQuit represents a "No to all" answer.
+	BoolAnswerQuit BoolAnswer = 3
 )
 
 // AskBool asks a yes or no question with the choice of cancelling.
 func (e *Editor) AskBool(question string) BoolAnswer {
+	return e.askBool(question, false)
+}
+
+// AskBoolAll asks a yes or no question which is repeated for several items,
+// with the additional choices of answering yes to all remaining items or
+// quitting.
+func (e *Editor) AskBoolAll(question string) BoolAnswer {
+	return e.askBool(question, true)
+}
+
+// askBool asks a yes or no question, optionally accepting "all" and "quit" as
+// answers as well.
+func (e *Editor) askBool(question string, allowAll bool) BoolAnswer {
 
-	// Restore the cursor position and close the prompt when the function exits.
-	defer e.restoreCursor(e.FB().CursorX, e.FB().CursorY)
+	// Close the prompt when the function exits.
 	defer e.closePrompt()
 
 	// Activate the prompt and poll events until the user responds or cancels.
@@ -185,7 +401,7 @@ func (e *Editor) AskBool(question string) BoolAnswer {
 	for {
 		e.Draw()
 
-		switch event := termbox.PollEvent(); event.Type {
+		switch event := e.PollEvent(); event.Type {
 		case termbox.EventKey:
 			switch event.Key {
 			case termbox.KeyCtrlC:
@@ -196,6 +412,54 @@ func (e *Editor) AskBool(question string) BoolAnswer {
 					return BoolAnswerYes
 				case 'N':
 					return BoolAnswerNo
+				case 'A':
+					if allowAll {
+						return BoolAnswerAll
+					}
+				case 'Q':
+					if allowAll {
+						return BoolAnswerQuit
+					}
+				}
+			}
+		}
+	}
+}
+
+// AskChoice asks the user to choose one of several answers, each of which is
+// picked by typing a letter in choices. The chosen letter is returned in upper
+// case, or zero if the user cancels. The focused buffer can be scrolled while
+// the question is asked.
+func (e *Editor) AskChoice(question, choices string) rune {
+
+	// Close the prompt when the function exits.
+	defer e.closePrompt()
+
+	// Activate the prompt and poll events until the user responds or cancels.
+	e.activatePrompt(question, "")
+	for {
+		e.Draw()
+
+		event := e.PollEvent()
+		if event.Type != termbox.EventKey {
+			continue
+		}
+
+		switch event.Key {
+		case termbox.KeyCtrlC:
+			return 0
+		case termbox.KeyArrowUp:
+			e.MoveCursor(CursorMoveUp)
+		case termbox.KeyArrowDown:
+			e.MoveCursor(CursorMoveDown)
+		case termbox.KeyPgup:
+			e.MoveCursor(CursorMovePageUp)
+		case termbox.KeyPgdn:
+			e.MoveCursor(CursorMovePageDown)
+		default:
+			for _, c := range choices {
+				if unicode.ToUpper(event.Ch) == c {
+					return c
 				}
 			}
 		}
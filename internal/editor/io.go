@@ -1,24 +1,118 @@
 package editor
 
 import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/jonpalmisc/atto/internal/buffer"
+	"github.com/jonpalmisc/atto/internal/support"
+	"github.com/nsf/termbox-go"
 )
 
+// fileLimit is the largest number of files listed by the file finder.
+const fileLimit = 50000
+
 // Open prompts the user for a path and creates a new buffer for it.
 func (e *Editor) Open() {
-	path, err := e.Ask("Open file: ", "")
+	path, err := e.askPath("Open file: ", "")
 	if err != nil {
 		e.SetStatusMessage("User cancelled operation.")
 		return
 	}
 
+	e.openBuffer(path)
+}
+
+// openBuffer creates a buffer for a path and focuses it, offering to recover
+// unsaved changes left behind for the file by an earlier session.
+func (e *Editor) openBuffer(path string) {
 	b, err := buffer.Create(&e.Config, path)
 	if err != nil {
 		e.SetStatusMessage("Error: %v", err)
+		return
 	}
 
 	e.Buffers = append(e.Buffers, b)
 	e.FocusIndex = e.BufferCount() - 1
+
+	e.offerRecovery(b)
+}
+
+// FindFile lets the user pick a file to open from the files inside of the
+// working directory, filtered as they type. If the file is open already, its
+// buffer is focused instead.
+func (e *Editor) FindFile() {
+	files, err := support.ProjectFiles(".", fileLimit)
+	if err != nil {
+		e.SetStatusMessage("Error: %v.", err)
+		return
+	} else if len(files) == 0 {
+		e.SetStatusMessage("No files found.")
+		return
+	}
+
+	items := make([]pickerItem, 0, len(files))
+	for _, f := range files {
+		item := pickerItem{Text: f}
+		if t := support.GuessFileType(f); t != support.FileTypeUnknown {
+			item.Note = string(t)
+		}
+
+		items = append(items, item)
+	}
+
+	// Previews are read once per file and kept while the picker is open.
+	previews := make(map[int][]string)
+	preview := func(i int) []string {
+		if _, ok := previews[i]; !ok {
+			previews[i] = previewFile(files[i], e.Height)
+		}
+
+		return previews[i]
+	}
+
+	p := &picker{Title: "Files", Hint: "Enter: Open | ^C: Cancel", Items: items, Preview: preview}
+	if i, ok := e.pick("Find file: ", p, nil); ok {
+		e.openOrFocus(files[i])
+	}
+}
+
+// previewFile returns up to n lines from the start of a file.
+func previewFile(path string, n int) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return []string{fmt.Sprintf("Cannot preview file. (%v)", err)}
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for len(lines) < n && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines
+}
+
+// openOrFocus focuses the buffer of a file if it is open already, and opens a
+// new buffer for it otherwise.
+func (e *Editor) openOrFocus(path string) {
+	target, err := filepath.Abs(path)
+	if err != nil {
+		e.SetStatusMessage("Error: %v.", err)
+		return
+	}
+
+	for i, b := range e.Buffers {
+		if abs, err := filepath.Abs(b.Path); err == nil && abs == target {
+			e.focusBuffer(i)
+			return
+		}
+	}
+
+	e.openBuffer(path)
 }
 
 // Save writes the current buffer back to the file it was read from.
@@ -28,23 +122,111 @@ func (e *Editor) Save() {
 		return
 	}
 
-	path, err := e.Ask("Save: ", e.FB().Path)
+	path, err := e.askPath("Save: ", e.FB().Path)
 	if err != nil {
 		e.SetStatusMessage("Save cancelled.")
 		return
 	}
 
-	err = e.FB().Write(path)
-	if err != nil {
+	e.SaveAs(path)
+}
+
+// SaveAs writes the current buffer to the file at the given path.
+func (e *Editor) SaveAs(path string) {
+	if e.FB().IsReadOnly {
+		e.SetStatusMessage("Warning: Read-only buffers cannot be saved.")
+		return
+	}
+
+	// Make sure changes made by other programs are not overwritten by mistake.
+	if path == e.FB().Path && !e.checkDiskChanges(e.FB()) {
+		return
+	}
+
+	if err := e.FB().Write(path); err != nil {
 		e.SetStatusMessage("Error: %v.", err)
 	} else {
 		e.SetStatusMessage("File saved successfully. (%v)", path)
 	}
 }
 
+// checkDiskChanges asks the user whether to reload a buffer if its file was
+// changed by another program, optionally after reviewing the changes as a
+// diff. Clean buffers are reloaded without asking if the user's config says
+// so. It returns false if the buffer was reloaded or the user cancelled.
+func (e *Editor) checkDiskChanges(b *buffer.Buffer) bool {
+	if !b.HasChangedOnDisk() {
+		return true
+	}
+
+	var choice rune
+	if e.Config.AutoReload && !b.IsDirty {
+		choice = 'Y'
+	} else {
+		question := fmt.Sprintf("%v changed on disk. Reload? [Y]es/[N]o/[D]iff: ", b.FileName())
+		if choice = e.AskChoice(question, "YND"); choice == 'D' {
+			choice = e.showDiskDiff(b)
+		}
+	}
+
+	switch choice {
+	case 'Y':
+		if err := b.Reload(); err != nil {
+			e.SetStatusMessage("Error: Failed to reload %v. (%v)", b.FileName(), err)
+		} else {
+			e.SetStatusMessage("Reloaded %v.", b.FileName())
+		}
+		return false
+	case 'N':
+		b.IgnoreDiskChanges()
+		return true
+	default:
+		e.SetStatusMessage("User cancelled operation.")
+		return false
+	}
+}
+
+// showDiskDiff shows how a buffer differs from the new version of its file,
+// and asks the user whether to reload it while it is shown.
+func (e *Editor) showDiskDiff(b *buffer.Buffer) rune {
+	lines, err := b.DiskStrings()
+	if err != nil {
+		e.SetStatusMessage("Error: Failed to read %v. (%v)", b.FileName(), err)
+		return 0
+	}
+
+	name := b.FileName()
+	return e.showDiff(name, name+" (buffer)", name+" (on disk)", b.Strings(), lines, "Reload? [Y/N]: ", "YN")
+}
+
+// focusBuffer focuses the buffer at index i and checks whether its file was
+// changed by another program.
+func (e *Editor) focusBuffer(i int) {
+	e.FocusIndex = i
+	e.checkDiskChanges(e.FB())
+}
+
+// ToggleLineEnding converts the focused buffer between LF and CRLF line
+// endings. The conversion takes effect when the buffer is saved.
+func (e *Editor) ToggleLineEnding() {
+	b := e.FB()
+	if b.IsReadOnly {
+		e.SetStatusMessage("Warning: Read-only buffers cannot be modified.")
+		return
+	}
+
+	if b.Format.LineEnding == buffer.LineEndingLF {
+		b.SetLineEnding(buffer.LineEndingCRLF)
+	} else {
+		b.SetLineEnding(buffer.LineEndingLF)
+	}
+
+	e.SetStatusMessage("Line endings converted to %v.", b.Format.LineEnding)
+}
+
 // Close closes the focused buffer.
 func (e *Editor) Close(i int) {
-	b := &e.Buffers[i]
+	b := e.Buffers[i]
 
 	if b.IsDirty {
 		switch e.AskBool("Save changes? [Y/N]: ") {
@@ -58,5 +240,96 @@ func (e *Editor) Close(i int) {
 		}
 	}
 
+	// The buffer's unsaved changes are no longer wanted.
+	b.RemoveSwap()
+
 	e.Buffers = append(e.Buffers[:i], e.Buffers[i+1:]...)
+
+	// Keep the focus on the same buffer if another one was closed, or on the
+	// new last buffer if the last one was closed.
+	if i < e.FocusIndex || e.FocusIndex == e.BufferCount() && e.FocusIndex > 0 {
+		e.FocusIndex--
+	}
+}
+
+// bufferIndex returns the index of an open buffer, or -1 if it is not open.
+func (e *Editor) bufferIndex(b *buffer.Buffer) int {
+	for i, o := range e.Buffers {
+		if o == b {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// SwitchBuffer lets the user pick a buffer to focus from a list of the open
+// buffers, filtered as they type. Buffers can be closed from the list too.
+func (e *Editor) SwitchBuffer() {
+	p := &picker{Title: "Buffers", Hint: "Enter: Switch | ^X: Close buffer | ^C: Cancel", Items: e.bufferItems()}
+
+	i, ok := e.pick("Switch to: ", p, func(event termbox.Event) bool {
+		if event.Key != termbox.KeyCtrlX {
+			return false
+		}
+
+		i, ok := p.current()
+		if !ok {
+			return true
+		}
+
+		// Closing a buffer may ask questions of its own, after which the
+		// picker's prompt is restored. The focus stays where it was unless
+		// the focused buffer was the one closed.
+		question, answer, focused := e.PromptQuestion, e.PromptAnswer, e.FB()
+
+		e.FocusIndex = i
+		e.Close(i)
+		if e.BufferCount() == 0 {
+			return true
+		}
+
+		if j := e.bufferIndex(focused); j >= 0 {
+			e.FocusIndex = j
+		}
+
+		e.activatePrompt(question, answer)
+		p.Items = e.bufferItems()
+		p.refresh()
+		return true
+	})
+
+	if ok {
+		e.focusBuffer(i)
+	}
+}
+
+// bufferItems returns the items listing the open buffers in a picker.
+func (e *Editor) bufferItems() []pickerItem {
+	items := make([]pickerItem, 0, e.BufferCount())
+
+	for _, b := range e.Buffers {
+		flag := " "
+		if b.IsDirty {
+			flag = "*"
+		}
+
+		items = append(items, pickerItem{Flag: flag, Text: b.FileName(), Detail: b.Path, Note: b.TypeName()})
+	}
+
+	return items
+}
+
+// Undo reverts the most recent edit to the focused buffer.
+func (e *Editor) Undo() {
+	if !e.FB().Undo() {
+		e.SetStatusMessage("Nothing to undo.")
+	}
+}
+
+// Redo reapplies the most recently undone edit to the focused buffer.
+func (e *Editor) Redo() {
+	if !e.FB().Redo() {
+		e.SetStatusMessage("Nothing to redo.")
+	}
 }
@@ -0,0 +1,193 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Chord is a single key press, possibly with modifiers, as written in a key
+// binding. Characters are identified by Ch and every other key by Key.
+type Chord struct {
+	Key termbox.Key
+	Ch  rune
+	Mod termbox.Modifier
+}
+
+// keyNames maps the names of special keys to termbox keys.
+var keyNames = map[string]termbox.Key{
+	"f1": termbox.KeyF1, "f2": termbox.KeyF2, "f3": termbox.KeyF3, "f4": termbox.KeyF4,
+	"f5": termbox.KeyF5, "f6": termbox.KeyF6, "f7": termbox.KeyF7, "f8": termbox.KeyF8,
+	"f9": termbox.KeyF9, "f10": termbox.KeyF10, "f11": termbox.KeyF11, "f12": termbox.KeyF12,
+
+	"insert": termbox.KeyInsert, "delete": termbox.KeyDelete,
+	"home": termbox.KeyHome, "end": termbox.KeyEnd,
+	"pgup": termbox.KeyPgup, "pgdn": termbox.KeyPgdn,
+	"up": termbox.KeyArrowUp, "down": termbox.KeyArrowDown,
+	"left": termbox.KeyArrowLeft, "right": termbox.KeyArrowRight,
+
+	"esc": termbox.KeyEsc, "enter": termbox.KeyEnter, "tab": termbox.KeyTab,
+	"backspace": termbox.KeyBackspace2, "space": termbox.KeySpace,
+}
+
+// ctrlKeyNames maps the names of keys pressed with Ctrl to the control
+// characters terminals send for them, besides the letters. Terminals which send
+// ^? for Backspace usually send ^H for Ctrl-Backspace.
+var ctrlKeyNames = map[string]termbox.Key{
+	"space": termbox.KeyCtrlSpace, "@": termbox.KeyCtrlSpace,
+	"[": termbox.KeyEsc, "\\": termbox.KeyCtrlBackslash,
+	"]": termbox.KeyCtrlRsqBracket, "^": termbox.KeyCtrl6, "6": termbox.KeyCtrl6,
+	"_": termbox.KeyCtrlUnderscore, "/": termbox.KeyCtrlSlash,
+	"backspace": termbox.KeyBackspace,
+}
+
+// ParseChord parses a chord written as modifiers and a key joined by plus
+// signs, such as "ctrl+s", "alt+x", "shift+up" or "f5".
+func ParseChord(s string) (Chord, error) {
+	var c Chord
+
+	parts := strings.Split(s, "+")
+
+	// Two trailing empty parts mean the key itself is a plus sign.
+	if len(parts) > 1 && parts[len(parts)-1] == "" && parts[len(parts)-2] == "" {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	key := parts[len(parts)-1]
+	ctrl := false
+	for _, m := range parts[:len(parts)-1] {
+		switch strings.ToLower(m) {
+		case "ctrl":
+			ctrl = true
+		case "alt":
+			c.Mod |= termbox.ModAlt
+		case "shift":
+			c.Mod |= ModShift
+		default:
+			return Chord{}, fmt.Errorf("unknown modifier \"%v\" in \"%v\"", m, s)
+		}
+	}
+
+	lower := strings.ToLower(key)
+	if k, ok := keyNames[lower]; ok && !ctrl {
+		c.Key = k
+	} else if k, ok := keyNames[lower]; ok && k >= termbox.KeyArrowRight {
+		c.Key, c.Mod = k, c.Mod|ModCtrl
+	} else if k, ok := ctrlKeyNames[lower]; ok && ctrl {
+		c.Key = k
+	} else if r, size := utf8.DecodeRuneInString(key); size == len(key) && size > 0 {
+		if !ctrl {
+			c.Ch = r
+		} else if r = []rune(lower)[0]; r >= 'a' && r <= 'z' {
+			c.Key = termbox.KeyCtrlA + termbox.Key(r-'a')
+		} else {
+			return Chord{}, fmt.Errorf("\"%v\" cannot be pressed with ctrl", key)
+		}
+	} else {
+		return Chord{}, fmt.Errorf("unknown key \"%v\"", key)
+	}
+
+	return c, nil
+}
+
+// ParseKeys parses a sequence of chords separated by spaces, such as
+// "ctrl+x ctrl+s".
+func ParseKeys(s string) ([]Chord, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty key sequence")
+	}
+
+	var keys []Chord
+	for _, f := range fields {
+		c, err := ParseChord(f)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, c)
+	}
+
+	return keys, nil
+}
+
+// eventChord returns the chord for a key event.
+func eventChord(event termbox.Event) Chord {
+	if event.Ch != 0 {
+		return Chord{Ch: event.Ch, Mod: event.Mod}
+	}
+
+	return Chord{Key: event.Key, Mod: event.Mod}
+}
+
+// keyName returns the name of a key which is not a character.
+func keyName(k termbox.Key) string {
+	for name, key := range keyNames {
+		if key == k {
+			return name
+		}
+	}
+
+	if k >= termbox.KeyCtrlA && k <= termbox.KeyCtrlZ {
+		return "ctrl+" + string(rune('a'+k-termbox.KeyCtrlA))
+	}
+
+	for _, name := range []string{"space", "\\", "]", "^", "_"} {
+		if ctrlKeyNames[name] == k {
+			return "ctrl+" + name
+		}
+	}
+
+	return fmt.Sprintf("key%v", int(k))
+}
+
+// String returns the canonical name of the chord, as it would be written in a
+// key binding.
+func (c Chord) String() string {
+	var prefix string
+	if c.Mod&ModCtrl != 0 {
+		prefix += "ctrl+"
+	}
+	if c.Mod&termbox.ModAlt != 0 {
+		prefix += "alt+"
+	}
+	if c.Mod&ModShift != 0 {
+		prefix += "shift+"
+	}
+
+	if c.Ch != 0 {
+		return prefix + string(c.Ch)
+	}
+
+	return prefix + keyName(c.Key)
+}
+
+// Label returns a short name for the chord for the help screen, in which Ctrl
+// with a single character is written as a caret, as in "^S". Named keys such
+// as "ctrl+space" are written out in full.
+func (c Chord) Label() string {
+	name := c.String()
+	if i := strings.Index(name, "ctrl+"); c.Mod&ModCtrl == 0 && i >= 0 {
+		if key := []rune(name[i+len("ctrl+"):]); len(key) == 1 {
+			name = name[:i] + "^" + strings.ToUpper(string(key))
+		}
+	}
+
+	return name
+}
+
+// keysString returns the canonical name of a sequence of chords.
+func keysString(keys []Chord, label bool) string {
+	var names []string
+	for _, c := range keys {
+		if label {
+			names = append(names, c.Label())
+		} else {
+			names = append(names, c.String())
+		}
+	}
+
+	return strings.Join(names, " ")
+}
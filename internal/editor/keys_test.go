@@ -0,0 +1,139 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+func TestParseChord(t *testing.T) {
+	tests := []struct {
+		written string
+		chord   Chord
+	}{
+		{"a", Chord{Ch: 'a'}},
+		{"A", Chord{Ch: 'A'}},
+		{"+", Chord{Ch: '+'}},
+		{"é", Chord{Ch: 'é'}},
+		{"f5", Chord{Key: termbox.KeyF5}},
+		{"F5", Chord{Key: termbox.KeyF5}},
+		{"esc", Chord{Key: termbox.KeyEsc}},
+		{"backspace", Chord{Key: termbox.KeyBackspace2}},
+		{"ctrl+s", Chord{Key: termbox.KeyCtrlS}},
+		{"Ctrl+S", Chord{Key: termbox.KeyCtrlS}},
+		{"ctrl+space", Chord{Key: termbox.KeyCtrlSpace}},
+		{"ctrl+@", Chord{Key: termbox.KeyCtrlSpace}},
+		{"ctrl+\\", Chord{Key: termbox.KeyCtrlBackslash}},
+		{"ctrl+]", Chord{Key: termbox.KeyCtrlRsqBracket}},
+		{"ctrl+backspace", Chord{Key: termbox.KeyBackspace}},
+		{"ctrl+h", Chord{Key: termbox.KeyCtrlH}},
+		{"ctrl+i", Chord{Key: termbox.KeyTab}},
+		{"ctrl+up", Chord{Key: termbox.KeyArrowUp, Mod: ModCtrl}},
+		{"ctrl+shift+home", Chord{Key: termbox.KeyHome, Mod: ModCtrl | ModShift}},
+		{"alt+x", Chord{Ch: 'x', Mod: termbox.ModAlt}},
+		{"alt++", Chord{Ch: '+', Mod: termbox.ModAlt}},
+		{"alt+backspace", Chord{Key: termbox.KeyBackspace2, Mod: termbox.ModAlt}},
+		{"shift+up", Chord{Key: termbox.KeyArrowUp, Mod: ModShift}},
+		{"ctrl+alt+v", Chord{Key: termbox.KeyCtrlV, Mod: termbox.ModAlt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.written, func(t *testing.T) {
+			c, err := ParseChord(tt.written)
+			if err != nil {
+				t.Fatalf("ParseChord(%q) failed: %v", tt.written, err)
+			}
+			if c != tt.chord {
+				t.Errorf("ParseChord(%q) = %+v, want %+v", tt.written, c, tt.chord)
+			}
+		})
+	}
+}
+
+func TestParseChordErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"ab",
+		"ctrl+",
+		"meta+x",
+		"ctrl+1",
+		"ctrl+é",
+		"ctrl+f13",
+	}
+
+	for _, written := range tests {
+		if c, err := ParseChord(written); err == nil {
+			t.Errorf("ParseChord(%q) = %+v, want an error", written, c)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		written string
+		keys    []Chord
+		err     bool
+	}{
+		{written: "ctrl+s", keys: []Chord{{Key: termbox.KeyCtrlS}}},
+		{written: "ctrl+x ctrl+c", keys: []Chord{{Key: termbox.KeyCtrlX}, {Key: termbox.KeyCtrlC}}},
+		{written: "  ctrl+w   v ", keys: []Chord{{Key: termbox.KeyCtrlW}, {Ch: 'v'}}},
+		{written: "", err: true},
+		{written: "   ", err: true},
+		{written: "ctrl+x nope", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.written, func(t *testing.T) {
+			keys, err := ParseKeys(tt.written)
+			if tt.err {
+				if err == nil {
+					t.Errorf("ParseKeys(%q) = %+v, want an error", tt.written, keys)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseKeys(%q) failed: %v", tt.written, err)
+			}
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("ParseKeys(%q) = %+v, want %+v", tt.written, keys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestChordNames(t *testing.T) {
+	tests := []struct {
+		written string
+		name    string
+		label   string
+	}{
+		{"ctrl+s", "ctrl+s", "^S"},
+		{"ctrl+\\", "ctrl+\\", "^\\"},
+		{"ctrl+space", "ctrl+space", "ctrl+space"},
+		{"ctrl+@", "ctrl+space", "ctrl+space"},
+		{"ctrl+backspace", "ctrl+h", "^H"},
+		{"ctrl+i", "tab", "tab"},
+		{"ctrl+up", "ctrl+up", "ctrl+up"},
+		{"alt+x", "alt+x", "alt+x"},
+		{"alt+ctrl+x", "alt+ctrl+x", "alt+^X"},
+		{"shift+f5", "shift+f5", "shift+f5"},
+		{"q", "q", "q"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.written, func(t *testing.T) {
+			c, err := ParseChord(tt.written)
+			if err != nil {
+				t.Fatalf("ParseChord(%q) failed: %v", tt.written, err)
+			}
+			if got := c.String(); got != tt.name {
+				t.Errorf("String() = %q, want %q", got, tt.name)
+			}
+			if got := c.Label(); got != tt.label {
+				t.Errorf("Label() = %q, want %q", got, tt.label)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package editor
+
+import (
+	"github.com/jonpalmisc/atto/internal/buffer"
+	"github.com/nsf/termbox-go"
+)
+
+// wheelLines is the number of lines scrolled by each turn of the mouse wheel.
+const wheelLines = 3
+
+// HandleMouse responds to a mouse event. Clicking text moves the cursor there
+// and focuses its pane, dragging selects text, clicking a buffer's name in the
+// title bar focuses the buffer, and the wheel scrolls the pane under the mouse
+// without moving the cursor.
+func (e *Editor) HandleMouse(event termbox.Event) {
+	x, y := event.MouseX, event.MouseY
+
+	switch event.Key {
+	case termbox.MouseRelease:
+		e.isDragging = false
+	case termbox.MouseWheelUp, termbox.MouseWheelDown:
+		if p := e.paneAt(x, y); p != nil {
+			lines := wheelLines
+			if event.Key == termbox.MouseWheelUp {
+				lines = -lines
+			}
+
+			e.scrollPane(p, lines)
+		}
+	case termbox.MouseLeft:
+		if event.Mod&termbox.ModMotion != 0 {
+			if e.isDragging {
+				e.dragTo(x, y)
+			}
+			return
+		}
+
+		e.click(x, y)
+	}
+}
+
+// click responds to a click of the left mouse button.
+func (e *Editor) click(x, y int) {
+	if y == 0 {
+		for _, t := range e.tabs {
+			if x >= t.start && x < t.end {
+				e.focusBuffer(t.index)
+				return
+			}
+		}
+
+		return
+	}
+
+	p := e.paneAt(x, y)
+	if p == nil {
+		return
+	}
+
+	e.focusPane(p)
+
+	// Clicking the pane's bar only focuses the pane.
+	if y >= p.Y+p.Height {
+		return
+	}
+
+	p.View.ClearSelection()
+	p.Buffer.SetCursor(e.positionAt(p, x, y))
+
+	p.isScrolled = false
+	e.isDragging = true
+}
+
+// dragTo extends the selection to the position under the mouse while it is
+// dragged, starting from where the mouse was clicked.
+func (e *Editor) dragTo(x, y int) {
+	p := e.FP()
+	if !p.View.IsSelecting {
+		p.View.StartSelection()
+	}
+
+	p.Buffer.SetCursor(e.positionAt(p, x, y))
+	p.isScrolled = false
+}
+
+// paneAt returns the pane at a position on the screen, including the bar below
+// it, or nil if there is no pane there.
+func (e *Editor) paneAt(x, y int) *Pane {
+	if e.layout == nil {
+		return nil
+	}
+
+	for _, p := range e.layout.panes() {
+		bottom := p.Y + p.Height
+		if p.HasBar {
+			bottom++
+		}
+
+		if x >= p.X && x < p.X+p.Width && y >= p.Y && y < bottom {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// positionAt returns the position in a pane's buffer shown at a position on the
+// screen. Positions above or below the text area refer to the lines just out
+// of view, so that dragging past the edges scrolls. Positions on a tab or a
+// wide rune refer to that rune.
+func (e *Editor) positionAt(p *Pane, x, y int) buffer.Position {
+	b := p.Buffer
+
+	column := x - p.X - e.GutterWidth(b)
+	if column < 0 {
+		column = 0
+	}
+
+	// While soft wrapping, find the row shown at the position and keep the
+	// position on that row.
+	if width := e.wrapWidth(p); width > 0 {
+		topI, topR := topRow(p, width)
+		i, r := walkRows(b, width, topI, topR, y-p.Y)
+
+		line := &b.Lines[i]
+		rows := line.WrapRows(width)
+
+		index := line.IndexAtColumn(line.AdjustedX(rows[r]) + column)
+		if r+1 < len(rows) && index >= rows[r+1] {
+			index = line.PreviousBoundary(rows[r+1])
+		}
+
+		return buffer.Position{X: index, Y: i + 1}
+	}
+
+	i := y - p.Y + p.View.OffsetY
+	if i >= b.Length() {
+		i = b.Length() - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+
+	return buffer.Position{X: b.Lines[i].IndexAtColumn(column + p.View.OffsetX), Y: i + 1}
+}
+
+// scrollPane scrolls a pane's view by a number of lines without moving the
+// cursor, which may leave the cursor out of view until it is moved.
+func (e *Editor) scrollPane(p *Pane, lines int) {
+	view := &p.View
+	view.OffsetY, view.OffsetRow = view.OffsetY+lines, 0
+	if max := p.Buffer.Length() - 1; view.OffsetY > max {
+		view.OffsetY = max
+	}
+	if view.OffsetY < 0 {
+		view.OffsetY = 0
+	}
+
+	p.isScrolled = true
+}
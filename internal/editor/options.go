@@ -0,0 +1,249 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonpalmisc/atto/internal/config"
+)
+
+// option is a configuration option which can be changed with the set command.
+type option struct {
+	Name string
+
+	// Values returns the values the option may be set to, for completion.
+	Values func() []string
+
+	// Get returns the option's current value and Set changes it.
+	Get func(e *Editor) string
+	Set func(e *Editor, value string) error
+}
+
+// boolValues are the values of boolean options.
+func boolValues() []string {
+	return []string{"true", "false"}
+}
+
+// parseBool parses the value of a boolean option.
+func parseBool(value string) (bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean \"%v\"", value)
+	}
+
+	return b, nil
+}
+
+// boolOption returns an option for a boolean field of the configuration.
+func boolOption(name string, field func(c *config.Config) *bool) option {
+	return option{
+		Name:   name,
+		Values: boolValues,
+		Get:    func(e *Editor) string { return strconv.FormatBool(*field(&e.Config)) },
+		Set: func(e *Editor, value string) error {
+			b, err := parseBool(value)
+			if err == nil {
+				*field(&e.Config) = b
+			}
+
+			return err
+		},
+	}
+}
+
+// choiceOption returns an option for a field of the configuration which takes
+// one of a fixed set of values. The apply function, if not nil, is called
+// after the option changes, and the option is changed back if it fails.
+func choiceOption(name string, values []string, field func(c *config.Config) *string, apply func(e *Editor) error) option {
+	return option{
+		Name:   name,
+		Values: func() []string { return values },
+		Get:    func(e *Editor) string { return *field(&e.Config) },
+		Set: func(e *Editor, value string) error {
+			for _, v := range values {
+				if v == value {
+					previous := *field(&e.Config)
+					*field(&e.Config) = value
+					if apply == nil {
+						return nil
+					}
+
+					err := apply(e)
+					if err != nil {
+						*field(&e.Config) = previous
+						apply(e)
+					}
+
+					return err
+				}
+			}
+
+			return fmt.Errorf("expected one of %v", strings.Join(values, ", "))
+		},
+	}
+}
+
+// options holds every option which can be changed with the set command.
+var options = []option{
+	{
+		Name: "tabsize",
+		Get:  func(e *Editor) string { return strconv.Itoa(e.Config.TabSize) },
+		Set: func(e *Editor, value string) error {
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 1 {
+				return fmt.Errorf("invalid tab size \"%v\"", value)
+			}
+
+			e.Config.TabSize = size
+			return nil
+		},
+	},
+	boolOption("softtabs", func(c *config.Config) *bool { return &c.UseSoftTabs }),
+	{
+		Name:   "highlighting",
+		Values: boolValues,
+		Get:    func(e *Editor) string { return strconv.FormatBool(e.Config.UseHighlighting) },
+		Set: func(e *Editor, value string) error {
+			b, err := parseBool(value)
+			if err != nil {
+				return err
+			}
+
+			e.Config.UseHighlighting = b
+			for _, buf := range e.Buffers {
+				buf.Rehighlight()
+			}
+
+			return nil
+		},
+	},
+	boolOption("fullpaths", func(c *config.Config) *bool { return &c.ShowFullPaths }),
+	boolOption("24hourtime", func(c *config.Config) *bool { return &c.Use24HourTime }),
+	choiceOption("linenumbers", []string{LineNumbersOff, LineNumbersAbsolute, LineNumbersRelative},
+		func(c *config.Config) *string { return &c.LineNumbers }, nil),
+	boolOption("softwrap", func(c *config.Config) *bool { return &c.SoftWrap }),
+	{
+		Name: "wrapcolumn",
+		Get:  func(e *Editor) string { return strconv.Itoa(e.Config.WrapColumn) },
+		Set: func(e *Editor, value string) error {
+			column, err := strconv.Atoi(value)
+			if err != nil || column < 0 {
+				return fmt.Errorf("invalid wrap column \"%v\"", value)
+			}
+
+			e.Config.WrapColumn = column
+			return nil
+		},
+	},
+	{
+		Name: "wordchars",
+		Get:  func(e *Editor) string { return e.Config.WordCharacters },
+		Set: func(e *Editor, value string) error {
+			e.Config.WordCharacters = value
+			return nil
+		},
+	},
+	boolOption("subwords", func(c *config.Config) *bool { return &c.SubwordMotion }),
+	boolOption("autopairs", func(c *config.Config) *bool { return &c.AutoPairs }),
+	choiceOption("backupmode", []string{"off", "simple", "timestamped"},
+		func(c *config.Config) *string { return &c.BackupMode }, nil),
+	boolOption("autoreload", func(c *config.Config) *bool { return &c.AutoReload }),
+	{
+		Name:   "theme",
+		Values: themeNames,
+		Get:    func(e *Editor) string { return e.Config.Theme },
+		Set: func(e *Editor, value string) error {
+			previous := e.Config.Theme
+			e.Config.Theme = value
+
+			// Go back to the previous theme if the new one fails to load.
+			err := e.loadTheme()
+			if err != nil {
+				e.Config.Theme = previous
+				e.loadTheme()
+			}
+
+			return err
+		},
+	},
+	choiceOption("colormode", []string{"auto", "16", "256", "truecolor"},
+		func(c *config.Config) *string { return &c.ColorMode }, (*Editor).loadTheme),
+}
+
+// findOption returns the option with the given name, or nil if there is no
+// such option.
+func findOption(name string) *option {
+	for i := range options {
+		if options[i].Name == name {
+			return &options[i]
+		}
+	}
+
+	return nil
+}
+
+// themeNames returns the names of the default theme and the user's themes.
+func themeNames() []string {
+	names := []string{"default"}
+
+	path, err := config.FolderPath("themes")
+	if err != nil {
+		return names
+	}
+
+	files, _ := ioutil.ReadDir(path)
+	for _, f := range files {
+		if name := f.Name(); !f.IsDir() && strings.HasSuffix(name, ".yml") {
+			names = append(names, strings.TrimSuffix(name, ".yml"))
+		}
+	}
+
+	sort.Strings(names[1:])
+	return names
+}
+
+func runSet(e *Editor, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("expected an option and a value")
+	}
+
+	o := findOption(args[0])
+	if o == nil {
+		return fmt.Errorf("unknown option \"%v\"", args[0])
+	}
+
+	// Show the option's value if no new value is given.
+	if len(args) == 1 {
+		e.SetStatusMessage("%v is %v.", o.Name, o.Get(e))
+		return nil
+	}
+
+	if err := o.Set(e, args[1]); err != nil {
+		return err
+	}
+
+	e.SetStatusMessage("Set %v to %v.", o.Name, o.Get(e))
+	return nil
+}
+
+func completeSet(e *Editor, args []string) []string {
+	switch len(args) {
+	case 1:
+		var names []string
+		for _, o := range options {
+			names = append(names, o.Name)
+		}
+
+		return names
+	case 2:
+		if o := findOption(args[0]); o != nil && o.Values != nil {
+			return o.Values()
+		}
+	}
+
+	return nil
+}
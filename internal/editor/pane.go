@@ -0,0 +1,224 @@
+package editor
+
+import (
+	"github.com/jonpalmisc/atto/internal/buffer"
+)
+
+// Pane is a window showing a buffer. Every pane has its own view of its
+// buffer, so that two panes can show the same buffer at different positions.
+type Pane struct {
+	Buffer *buffer.Buffer
+
+	// The pane's view of its buffer: its cursor, selection and scroll offsets.
+	// The focused pane's buffer is edited through this view.
+	View buffer.View
+
+	// The position and size of the area the buffer is drawn in, and whether
+	// the pane has a bar below it showing its buffer's name.
+	X, Y          int
+	Width, Height int
+	HasBar        bool
+
+	// Whether the pane was scrolled with the mouse wheel, in which case the
+	// cursor is not kept in view until it is used again.
+	isScrolled bool
+}
+
+// layout is a node of the tree the screen is split into. Leaves hold a pane,
+// while other nodes split their area between two children, either side by side
+// or one above the other.
+type layout struct {
+	pane *Pane
+
+	vertical bool
+	children [2]*layout
+	parent   *layout
+}
+
+// panes returns the panes in the layout, from left to right and top to bottom.
+func (l *layout) panes() []*Pane {
+	if l.pane != nil {
+		return []*Pane{l.pane}
+	}
+
+	return append(l.children[0].panes(), l.children[1].panes()...)
+}
+
+// find returns the leaf holding a pane.
+func (l *layout) find(p *Pane) *layout {
+	if l.pane != nil {
+		if l.pane == p {
+			return l
+		}
+
+		return nil
+	}
+
+	if found := l.children[0].find(p); found != nil {
+		return found
+	}
+
+	return l.children[1].find(p)
+}
+
+// arrange gives every pane in the layout its area of the screen. Side by side
+// panes are separated by a column, and the column, row and height of each
+// separator are added to seps.
+func (l *layout) arrange(x, y, width, height int, hasBar bool, seps *[][3]int) {
+	if l.pane != nil {
+		l.pane.X, l.pane.Y, l.pane.Width, l.pane.Height = x, y, width, height
+		l.pane.HasBar = hasBar
+		if hasBar {
+			l.pane.Height--
+		}
+
+		return
+	}
+
+	if l.vertical {
+		left := (width - 1) / 2
+		l.children[0].arrange(x, y, left, height, hasBar, seps)
+		l.children[1].arrange(x+left+1, y, width-left-1, height, hasBar, seps)
+		*seps = append(*seps, [3]int{x + left, y, height})
+	} else {
+		top := height / 2
+		l.children[0].arrange(x, y, width, top, hasBar, seps)
+		l.children[1].arrange(x, y+top, width, height-top, hasBar, seps)
+	}
+}
+
+// FP returns the focused pane.
+func (e *Editor) FP() *Pane {
+	e.syncPanes()
+	return e.pane
+}
+
+// syncPanes creates the first pane if there is none yet and makes sure every
+// pane shows an open buffer, with the focused pane showing the focused buffer
+// through its view.
+func (e *Editor) syncPanes() {
+	if e.layout == nil {
+		e.pane = &Pane{}
+		e.layout = &layout{pane: e.pane}
+		e.showBuffer(e.pane, e.FB())
+	}
+
+	for _, p := range e.layout.panes() {
+		if !e.isOpen(p.Buffer) {
+			e.showBuffer(p, e.FB())
+		}
+	}
+
+	if e.pane.Buffer != e.FB() {
+		e.showBuffer(e.pane, e.FB())
+	}
+
+	e.pane.Buffer.View = &e.pane.View
+}
+
+// showBuffer makes a pane show a buffer, starting from the view the buffer was
+// last edited through. The buffer the pane showed before keeps a copy of the
+// pane's view if it was edited through it.
+func (e *Editor) showBuffer(p *Pane, b *buffer.Buffer) {
+	if old := p.Buffer; old != nil && old.View == &p.View {
+		view := p.View
+		old.View = &view
+	}
+
+	p.Buffer, p.View = b, *b.View
+	p.isScrolled = false
+}
+
+// isOpen tells whether a buffer is one of the editor's open buffers.
+func (e *Editor) isOpen(b *buffer.Buffer) bool {
+	return e.bufferIndex(b) >= 0
+}
+
+// focusPane moves the focus to another pane, whose buffer is then edited
+// through the pane's view.
+func (e *Editor) focusPane(p *Pane) {
+	e.syncPanes()
+	if p == e.pane {
+		return
+	}
+
+	e.enterPane(p)
+	e.checkDiskChanges(p.Buffer)
+}
+
+// enterPane makes a pane the focused one, keeping its view inside of its buffer
+// in case the buffer was edited through another pane.
+func (e *Editor) enterPane(p *Pane) {
+	e.pane = p
+
+	p.Buffer.View = &p.View
+	p.Buffer.ClampView(&p.View)
+
+	e.FocusIndex = e.bufferIndex(p.Buffer)
+}
+
+// SplitPane splits the focused pane in two, showing the focused buffer in both
+// halves, and focuses the new half. If vertical is true, the halves are side by
+// side, otherwise they are above each other.
+func (e *Editor) SplitPane(vertical bool) {
+	e.syncPanes()
+
+	leaf := e.layout.find(e.pane)
+	p := &Pane{Buffer: e.pane.Buffer, View: e.pane.View}
+
+	// The leaf becomes the parent of the old pane and the new one.
+	old := &layout{pane: e.pane, parent: leaf}
+	leaf.pane, leaf.vertical = nil, vertical
+	leaf.children = [2]*layout{old, {pane: p, parent: leaf}}
+
+	e.focusPane(p)
+}
+
+// ClosePane closes the focused pane, giving its area to the neighbouring panes.
+// The last pane cannot be closed.
+func (e *Editor) ClosePane() {
+	e.syncPanes()
+
+	leaf := e.layout.find(e.pane)
+	if leaf.parent == nil {
+		e.SetStatusMessage("Cannot close the only pane.")
+		return
+	}
+
+	// Replace the parent with the closed pane's sibling.
+	parent := leaf.parent
+	sibling := parent.children[0]
+	if sibling == leaf {
+		sibling = parent.children[1]
+	}
+
+	parent.pane, parent.vertical, parent.children = sibling.pane, sibling.vertical, sibling.children
+	for _, c := range parent.children {
+		if c != nil {
+			c.parent = parent
+		}
+	}
+
+	// Focus the first pane of the area the closed pane was in.
+	e.enterPane(parent.panes()[0])
+}
+
+// CyclePane moves the focus to the next pane, or the previous one if backward
+// is true.
+func (e *Editor) CyclePane(backward bool) {
+	e.syncPanes()
+
+	panes := e.layout.panes()
+	for i, p := range panes {
+		if p == e.pane {
+			if backward {
+				i += len(panes) - 1
+			} else {
+				i++
+			}
+
+			e.focusPane(panes[i%len(panes)])
+			return
+		}
+	}
+}
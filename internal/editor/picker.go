@@ -0,0 +1,357 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jonpalmisc/atto/internal/support"
+	"github.com/nsf/termbox-go"
+)
+
+// pickerPreviewWidth is the smallest width of a picker which leaves room for a
+// preview of the selected item next to the list.
+const pickerPreviewWidth = 60
+
+// pickerItem is an item of a picker's list. Items are filtered by their text,
+// or by their detail if the text does not match. The flag is shown before the
+// text and the note is shown on the right.
+type pickerItem struct {
+	Flag   string
+	Text   string
+	Detail string
+	Note   string
+}
+
+// pickerMatch is an item which matches the filter, with the indices of the
+// matched runes in its text or detail.
+type pickerMatch struct {
+	index     int
+	score     int
+	positions []int
+	inDetail  bool
+}
+
+// picker is a list shown in a popup, which the user filters by typing into the
+// prompt and picks an item from.
+type picker struct {
+	Title string
+	Hint  string
+	Items []pickerItem
+
+	// Preview returns the lines previewing the item at an index, which are
+	// shown next to the list if it is not nil.
+	Preview func(i int) []string
+
+	// The filter typed so far, the items matching it from best to worst, the
+	// index of the selected match and the index of the first match in view.
+	filter   string
+	matches  []pickerMatch
+	selected int
+	offset   int
+}
+
+// setFilter filters the picker's items, selecting the best match.
+func (p *picker) setFilter(filter string) {
+	p.filter, p.selected = filter, 0
+	p.refresh()
+}
+
+// refresh filters the picker's items again after they changed, keeping the
+// selection in place.
+func (p *picker) refresh() {
+	p.matches = nil
+
+	for i, item := range p.Items {
+		if score, positions, ok := support.FuzzyMatch(p.filter, item.Text); ok {
+			p.matches = append(p.matches, pickerMatch{i, score, positions, false})
+		} else if score, positions, ok := support.FuzzyMatch(p.filter, item.Detail); ok {
+			p.matches = append(p.matches, pickerMatch{i, score - 100, positions, true})
+		}
+	}
+
+	// Items which match equally well keep their order.
+	sort.SliceStable(p.matches, func(i, j int) bool {
+		return p.matches[i].score > p.matches[j].score
+	})
+
+	p.move(0)
+}
+
+// move moves the selection by a number of items, keeping it inside the list.
+func (p *picker) move(delta int) {
+	p.selected += delta
+
+	if p.selected >= len(p.matches) {
+		p.selected = len(p.matches) - 1
+	}
+
+	if p.selected < 0 {
+		p.selected = 0
+	}
+}
+
+// current returns the index of the selected item, or false if no item matches
+// the filter.
+func (p *picker) current() (int, bool) {
+	if len(p.matches) == 0 {
+		return 0, false
+	}
+
+	return p.matches[p.selected].index, true
+}
+
+// pick shows a picker and lets the user filter its items and pick one. The
+// index of the picked item is returned, or false if the user cancels. Every
+// key event the picker does not use is offered to onKey, which may be nil, and
+// any other key edits the filter.
+func (e *Editor) pick(question string, p *picker, onKey func(event termbox.Event) bool) (int, bool) {
+
+	// Close the prompt and the picker when the function exits.
+	defer e.closePrompt()
+	defer func() { e.picker = nil }()
+
+	e.picker = p
+	e.activatePrompt(question, "")
+	p.setFilter("")
+
+	for {
+		e.Draw()
+
+		event := e.PollEvent()
+		if event.Type == EventPaste {
+			e.InsertPromptText(e.Pasted)
+			p.setFilter(e.PromptAnswer)
+		}
+
+		if event.Type != termbox.EventKey {
+			continue
+		}
+
+		switch event.Key {
+		case termbox.KeyCtrlC:
+			return 0, false
+		case termbox.KeyEnter:
+			if i, ok := p.current(); ok {
+				return i, true
+			}
+		case termbox.KeyArrowUp:
+			p.move(-1)
+		case termbox.KeyArrowDown:
+			p.move(1)
+		case termbox.KeyPgup:
+			p.move(-e.pickerRows())
+		case termbox.KeyPgdn:
+			p.move(e.pickerRows())
+		default:
+			if onKey != nil && onKey(event) {
+
+				// Every buffer may have been closed from the list, in which
+				// case there is nothing left to draw.
+				if e.BufferCount() == 0 {
+					return 0, false
+				}
+
+				continue
+			}
+
+			e.EditPrompt(event)
+			if e.PromptAnswer != p.filter {
+				p.setFilter(e.PromptAnswer)
+			}
+		}
+	}
+}
+
+// pickerRows returns the number of items the picker has room to show.
+func (e *Editor) pickerRows() int {
+	rows := e.Height - 6
+	if rows < 1 {
+		rows = 1
+	}
+
+	return rows
+}
+
+// DrawPicker draws the active picker as a popup in the middle of the screen,
+// with a bar above and below its list, and a preview of the selected item to
+// the right of the list if the picker has one and there is room for it.
+func (e *Editor) DrawPicker() {
+	p := e.picker
+
+	width := e.Width - 8
+	if width > 120 {
+		width = 120
+	} else if width < 20 {
+		width = e.Width
+	}
+
+	rows := len(p.matches)
+	if rows == 0 {
+		rows = 1
+	} else if rows > e.pickerRows() {
+		rows = e.pickerRows()
+	}
+
+	hasPreview := p.Preview != nil && width >= pickerPreviewWidth
+	if hasPreview {
+		rows = e.pickerRows()
+	}
+
+	// Scroll the list so that the selected item is in view.
+	if p.selected < p.offset {
+		p.offset = p.selected
+	} else if p.selected >= p.offset+rows {
+		p.offset = p.selected - rows + 1
+	}
+
+	x, y := (e.Width-width)/2, 2
+
+	barFg, barBg := e.Theme.TitleBar.Apply(termbox.ColorDefault, termbox.ColorDefault)
+	title := fmt.Sprintf(" %v (%v/%v)", p.Title, len(p.matches), len(p.Items))
+	e.drawPickerBar(x, y, width, title, barFg, barBg)
+	e.drawPickerBar(x, y+rows+1, width, " "+p.Hint, barFg, barBg)
+
+	if hasPreview {
+		listWidth := width * 2 / 5
+		e.drawPickerPreview(x+listWidth, y+1, width-listWidth, rows)
+		width = listWidth
+	}
+
+	for row := 0; row < rows; row++ {
+		e.clearPickerRow(x, y+1+row, width, termbox.ColorDefault, termbox.ColorDefault)
+	}
+
+	if len(p.matches) == 0 {
+		drawText([]rune("  No matches."), x, y+1, termbox.ColorDefault, termbox.ColorDefault)
+		return
+	}
+
+	// Line up the details of the items in view, unless a text is too long.
+	column := 0
+	for row := 0; row < rows && p.offset+row < len(p.matches); row++ {
+		if w := support.StringWidth(p.Items[p.matches[p.offset+row].index].Text); w > column && w < width/3 {
+			column = w
+		}
+	}
+
+	for row := 0; row < rows && p.offset+row < len(p.matches); row++ {
+		e.drawPickerItem(x, y+1+row, width, column, p.matches[p.offset+row], p.offset+row == p.selected)
+	}
+}
+
+// drawPickerPreview draws the preview of a picker's selected item, separated
+// from the list by a line.
+func (e *Editor) drawPickerPreview(x, y, width, rows int) {
+	var lines []string
+	if i, ok := e.picker.current(); ok {
+		lines = e.picker.Preview(i)
+	}
+
+	sepFg, sepBg := e.Theme.LineNumber.Apply(termbox.ColorDefault, termbox.ColorDefault)
+	fg, bg := e.Theme.Text.Apply(termbox.ColorDefault, termbox.ColorDefault)
+	tab := strings.Repeat(" ", e.Config.TabSize)
+
+	for row := 0; row < rows; row++ {
+		e.clearPickerRow(x, y+row, width, fg, bg)
+		termbox.SetCell(x, y+row, '│', sepFg, sepBg)
+
+		if row < len(lines) {
+			line := strings.Replace(lines[row], "\t", tab, -1)
+			drawText(clipText(line, width-2), x+2, y+row, fg, bg)
+		}
+	}
+}
+
+// drawPickerBar draws one of the bars of a picker.
+func (e *Editor) drawPickerBar(x, y, width int, text string, fg, bg termbox.Attribute) {
+	e.clearPickerRow(x, y, width, fg, bg)
+	drawText(clipText(text, width), x, y, fg, bg)
+}
+
+// clearPickerRow fills a row of a picker with spaces.
+func (e *Editor) clearPickerRow(x, y, width int, fg, bg termbox.Attribute) {
+	for i := 0; i < width; i++ {
+		termbox.SetCell(x+i, y, ' ', fg, bg)
+	}
+}
+
+// drawPickerItem draws an item of a picker's list, highlighting the runes
+// which match the filter. The item's detail is drawn after the text, starting
+// at least a number of columns after it.
+func (e *Editor) drawPickerItem(x, y, width, column int, m pickerMatch, selected bool) {
+	item := e.picker.Items[m.index]
+
+	fg, bg := e.Theme.Text.Apply(termbox.ColorDefault, termbox.ColorDefault)
+	if selected {
+		fg, bg = e.Theme.Selection.Apply(fg, bg)
+	}
+
+	dimFg, dimBg := e.Theme.LineNumber.Apply(fg, bg)
+	matchFg, matchBg := e.Theme.Match.Apply(fg, bg)
+	e.clearPickerRow(x, y, width, fg, bg)
+
+	// The note is drawn first, so that the text and detail can stop before it.
+	end := x + width - 1
+	if note := []rune(item.Note); len(note) > 0 && support.StringWidth(item.Note)+2 < width/2 {
+		end -= support.StringWidth(item.Note) + 1
+		drawText(note, end+1, y, dimFg, dimBg)
+	}
+
+	col := x + 1
+	draw := func(text string, positions []int, fg, bg termbox.Attribute) {
+		next := 0
+		for i, c := range []rune(text) {
+			w := support.RuneWidth(c)
+			if w == 0 {
+				continue
+			} else if col+w > end {
+				return
+			}
+
+			cfg, cbg := fg, bg
+			if next < len(positions) && positions[next] == i {
+				cfg, cbg = matchFg, matchBg
+				next++
+			}
+
+			termbox.SetCell(col, y, c, cfg, cbg)
+			col += w
+		}
+	}
+
+	flag := item.Flag + " "
+	if item.Flag == "" {
+		flag = ""
+	}
+
+	textPositions, detailPositions := m.positions, []int(nil)
+	if m.inDetail {
+		textPositions, detailPositions = nil, m.positions
+	}
+
+	draw(flag, nil, fg, bg)
+	draw(item.Text, textPositions, fg, bg)
+
+	if pad := column - support.StringWidth(item.Text); pad > 0 {
+		draw(strings.Repeat(" ", pad), nil, fg, bg)
+	}
+
+	draw("  ", nil, fg, bg)
+	draw(item.Detail, detailPositions, dimFg, dimBg)
+}
+
+// clipText returns the runes of a string which fit into a number of columns.
+func clipText(s string, width int) []rune {
+	var clipped []rune
+
+	for _, c := range s {
+		if width -= support.RuneWidth(c); width < 0 {
+			break
+		}
+
+		clipped = append(clipped, c)
+	}
+
+	return clipped
+}
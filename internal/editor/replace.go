@@ -0,0 +1,122 @@
+package editor
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/jonpalmisc/atto/internal/buffer"
+)
+
+// Replace prompts the user for a regular expression and a replacement, then
+// replaces matches in the focused buffer, starting at the cursor and wrapping
+// around the buffer. Each replacement is confirmed unless the user chooses to
+// replace all remaining matches. The replacements are undone as one step.
+func (e *Editor) Replace() {
+	b := e.FB()
+	if b.IsReadOnly {
+		e.SetStatusMessage("Warning: Read-only buffers cannot be modified.")
+		return
+	}
+
+	pattern, err := e.Ask("Replace (regex): ", e.Search.Pattern)
+	if err != nil {
+		e.SetStatusMessage("Replace cancelled.")
+		return
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		e.SetStatusMessage("Error: Invalid pattern. (%v)", err)
+		return
+	}
+
+	template, err := e.Ask("Replace with: ", e.Search.Replacement)
+	if err != nil {
+		e.SetStatusMessage("Replace cancelled.")
+		return
+	}
+
+	e.Search.Pattern, e.Search.Replacement = pattern, template
+
+	count := e.replaceMatches(re, template)
+	if count == 0 {
+		e.SetStatusMessage("No matches for \"%v\".", pattern)
+	} else {
+		e.SetStatusMessage("Replaced %v occurrence(s).", count)
+	}
+}
+
+// replaceMatches performs the replacements for Replace and returns how many
+// matches were replaced.
+func (e *Editor) replaceMatches(re *regexp.Regexp, template string) (count int) {
+	b := e.FB()
+
+	b.BeginGroup()
+	defer b.EndGroup()
+
+	origin := buffer.Position{X: b.CursorX, Y: b.CursorY}
+	from := origin
+	wrapped, all := false, false
+
+	for {
+		m, submatches, ok := b.FindPattern(re, from)
+
+		// Once the end of the buffer is reached, continue from the top until
+		// the starting position is reached.
+		if !ok {
+			if wrapped {
+				break
+			}
+
+			wrapped, from = true, buffer.Position{X: 0, Y: 1}
+			continue
+		} else if wrapped && !m.Start.Before(origin) {
+			break
+		}
+
+		if !all {
+
+			// Select the match so the user can see what is being replaced.
+			b.CursorX, b.CursorY = m.Start.X, m.Start.Y
+			b.Anchor, b.IsSelecting = m.End, true
+
+			answer := e.AskBoolAll("Replace this match? [Y/N/A/Q]: ")
+			b.ClearSelection()
+
+			if answer == BoolAnswerNo {
+				from = nextSearchPosition(m, m.End)
+				continue
+			} else if answer == BoolAnswerCancel || answer == BoolAnswerQuit {
+				break
+			}
+
+			all = answer == BoolAnswerAll
+		}
+
+		line := b.Lines[m.Start.Y-1].Text
+		text := string(re.ExpandString(nil, template, line, submatches))
+		b.Replace(m.Start, m.End, text)
+		count++
+
+		// Replacing text before the starting position on the same line shifts
+		// the starting position as well.
+		if wrapped && m.Start.Y == origin.Y {
+			origin.X += utf8.RuneCountInString(text) - (m.End.X - m.Start.X)
+		}
+
+		from = nextSearchPosition(m, buffer.Position{X: b.CursorX, Y: b.CursorY})
+	}
+
+	return count
+}
+
+// nextSearchPosition returns the position to continue searching from after a
+// match ending at the given position. Empty matches are skipped past so that
+// the same match is not found again.
+func nextSearchPosition(m buffer.Match, end buffer.Position) buffer.Position {
+	if m.Start == m.End {
+		end.X++
+	}
+
+	return end
+}
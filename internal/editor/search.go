@@ -0,0 +1,167 @@
+package editor
+
+import (
+	"sort"
+
+	"github.com/jonpalmisc/atto/internal/buffer"
+	"github.com/nsf/termbox-go"
+)
+
+// Search holds the state of the current search.
+type Search struct {
+
+	// The query being searched for and whether it is being typed.
+	Query    string
+	IsActive bool
+
+	// The last pattern and replacement template used for replacing.
+	Pattern     string
+	Replacement string
+
+	// The matches for the query in the focused buffer and the index of the
+	// match the cursor is on, or -1 if the cursor is not on a match.
+	Matches []buffer.Match
+	Index   int
+}
+
+// updateMatches searches the focused buffer for the query and selects the first
+// match at or after the given position, wrapping around the buffer.
+func (e *Editor) updateMatches(from buffer.Position) {
+	e.Search.Matches = e.FB().FindAll(e.Search.Query)
+	e.Search.Index = -1
+
+	if len(e.Search.Matches) == 0 {
+		return
+	}
+
+	e.Search.Index = sort.Search(len(e.Search.Matches), func(i int) bool {
+		return !e.Search.Matches[i].Start.Before(from)
+	}) % len(e.Search.Matches)
+
+	e.jumpToMatch()
+}
+
+// jumpToMatch moves the cursor to the start of the current match.
+func (e *Editor) jumpToMatch() {
+	m := e.Search.Matches[e.Search.Index]
+	e.FB().CursorX, e.FB().CursorY = m.Start.X, m.Start.Y
+}
+
+// cycleMatch moves to the next match, or the previous one if backward is true,
+// wrapping around the buffer.
+func (e *Editor) cycleMatch(backward bool) {
+	count := len(e.Search.Matches)
+	if count == 0 {
+		return
+	}
+
+	if backward {
+		e.Search.Index = (e.Search.Index - 1 + count) % count
+	} else {
+		e.Search.Index = (e.Search.Index + 1) % count
+	}
+
+	e.jumpToMatch()
+}
+
+// Find prompts the user for a query and incrementally moves the cursor to
+// matches as it is typed.
+func (e *Editor) Find() {
+	b := e.FB()
+	origin := buffer.Position{X: b.CursorX, Y: b.CursorY}
+
+	b.ClearSelection()
+	e.Search.IsActive = true
+	defer func() { e.Search.IsActive = false }()
+
+	onChange := func(answer string) {
+		e.Search.Query = answer
+		e.updateMatches(origin)
+
+		if len(e.Search.Matches) == 0 {
+			b.CursorX, b.CursorY = origin.X, origin.Y
+		}
+	}
+
+	onKey := func(event termbox.Event) bool {
+		switch event.Key {
+		case termbox.KeyArrowDown, termbox.KeyCtrlN:
+			e.cycleMatch(false)
+		case termbox.KeyArrowUp, termbox.KeyCtrlP:
+			e.cycleMatch(true)
+		default:
+			return false
+		}
+
+		return true
+	}
+
+	_, err := e.AskIncremental("Find: ", e.Search.Query, onChange, onKey)
+	if err != nil {
+		b.CursorX, b.CursorY = origin.X, origin.Y
+		e.SetStatusMessage("Search cancelled.")
+		return
+	}
+
+	e.reportMatch()
+}
+
+// FindNext moves the cursor to the next match of the last query, or to the
+// previous one if backward is true.
+func (e *Editor) FindNext(backward bool) {
+	if e.Search.Query == "" {
+		e.SetStatusMessage("No previous search.")
+		return
+	}
+
+	b := e.FB()
+	b.ClearSelection()
+
+	// Find the match nearest to the cursor, since the buffer may have changed
+	// since the last search.
+	cursor := buffer.Position{X: b.CursorX, Y: b.CursorY}
+	if !backward {
+		cursor.X++
+	}
+
+	e.updateMatches(cursor)
+	if backward && e.Search.Index >= 0 {
+		e.cycleMatch(true)
+	}
+
+	e.reportMatch()
+}
+
+// reportMatch shows the position of the current match in the status bar.
+func (e *Editor) reportMatch() {
+	if len(e.Search.Matches) == 0 {
+		e.SetStatusMessage("No matches for \"%v\".", e.Search.Query)
+	} else {
+		e.SetStatusMessage("Match %v of %v.", e.Search.Index+1, len(e.Search.Matches))
+	}
+}
+
+// matchRanges returns the rune index ranges of the matches on the line at index
+// i, along with the index of the current match among them, or -1.
+func (e *Editor) matchRanges(i int) (ranges [][2]int, current int) {
+	current = -1
+	if !e.Search.IsActive {
+		return nil, current
+	}
+
+	matches := e.Search.Matches
+
+	j := sort.Search(len(matches), func(j int) bool {
+		return matches[j].Start.Y >= i+1
+	})
+
+	for ; j < len(matches) && matches[j].Start.Y == i+1; j++ {
+		if j == e.Search.Index {
+			current = len(ranges)
+		}
+
+		ranges = append(ranges, [2]int{matches[j].Start.X, matches[j].End.X})
+	}
+
+	return ranges, current
+}
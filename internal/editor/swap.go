@@ -0,0 +1,74 @@
+package editor
+
+import (
+	"time"
+
+	"github.com/jonpalmisc/atto/internal/buffer"
+	"github.com/nsf/termbox-go"
+)
+
+// swapInterval is how often swap files are written for dirty buffers.
+const swapInterval = 2 * time.Second
+
+// startSwapTimer periodically interrupts event polling, so that swap files are
+// written even while no keys are pressed.
+func (e *Editor) startSwapTimer() {
+	go func() {
+		for range time.Tick(swapInterval) {
+			termbox.Interrupt()
+		}
+	}()
+}
+
+// writeSwapFiles writes the swap files of every buffer which changed since
+// they were last written.
+func (e *Editor) writeSwapFiles() {
+	for _, b := range e.Buffers {
+		if err := b.WriteSwap(); err != nil {
+			e.SetStatusMessage("Failed to write swap file! (%v)", err)
+		}
+	}
+}
+
+// offerRecovery asks the user what to do with a swap file left behind for a
+// buffer by an earlier session. The unsaved changes can be recovered or
+// discarded, optionally after reviewing them as a diff. If the user cancels,
+// the swap file is left alone until the file is opened again, and the buffer's
+// own swap file is written next to it in the meantime.
+func (e *Editor) offerRecovery(b *buffer.Buffer) {
+	if b.Swap == "" {
+		return
+	}
+
+	choice := e.AskChoice("Recover unsaved changes? [Y]es/[N]o/[D]iff: ", "YND")
+	if choice == 'D' {
+		choice = e.showSwapDiff(b)
+	}
+
+	switch choice {
+	case 'Y':
+		if err := b.Recover(); err != nil {
+			e.SetStatusMessage("Error: Failed to recover changes. (%v)", err)
+		} else {
+			e.SetStatusMessage("Recovered unsaved changes to %v.", b.FileName())
+		}
+	case 'N':
+		b.DiscardSwap()
+		e.SetStatusMessage("Discarded unsaved changes to %v.", b.FileName())
+	default:
+		e.SetStatusMessage("Kept the swap file for %v.", b.FileName())
+	}
+}
+
+// showSwapDiff shows how the swap file of a buffer differs from its file, and
+// asks the user to recover or discard the changes while it is shown.
+func (e *Editor) showSwapDiff(b *buffer.Buffer) rune {
+	lines, err := b.ReadSwap()
+	if err != nil {
+		e.SetStatusMessage("Error: Failed to read swap file. (%v)", err)
+		return 0
+	}
+
+	name := b.FileName()
+	return e.showDiff(name, name, name+" (unsaved)", b.Strings(), lines, "Recover these changes? [Y/N]: ", "YN")
+}
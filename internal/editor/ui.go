@@ -4,23 +4,21 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jonpalmisc/atto/internal/buffer"
 	"github.com/jonpalmisc/atto/internal/support"
+	"github.com/jonpalmisc/atto/internal/theme"
 	"github.com/nsf/termbox-go"
 )
 
-const (
-
-	// BarForeground is the foreground color of title/status bars.
-	BarForeground = termbox.ColorBlack
-
-	// BarBackground is the background color of title/status bars.
-	BarBackground = termbox.ColorWhite
-)
-
 // drawText is a helper function for drawing an array of runes left to right.
+// Wide runes occupy two columns and zero-width runes are skipped.
 func drawText(text []rune, ox, y int, fg, bg termbox.Attribute) {
-	for i := 0; i < len(text); i++ {
-		termbox.SetCell(ox+i, y, text[i], fg, bg)
+	x := ox
+	for _, c := range text {
+		if w := support.RuneWidth(c); w > 0 {
+			termbox.SetCell(x, y, c, fg, bg)
+			x += w
+		}
 	}
 }
 
@@ -33,40 +31,95 @@ func (e *Editor) uiLocalTime() string {
 	return time.Now().Local().Format("2006-01-02 3:04 PM")
 }
 
-// uiFileName gives the file name or the full path depending on the user's config.
-func (e *Editor) uiFileName() string {
+// uiFileName gives a buffer's file name or its full path depending on the
+// user's config.
+func (e *Editor) uiFileName(b *buffer.Buffer) string {
 	if e.Config.ShowFullPaths {
-		return e.FB().Path
+		return b.Path
 	}
 
-	return e.FB().FileName()
+	return b.FileName()
 }
 
-// DrawTitleBar draws the editor's title bar at the top of the screen.
+// titleTab is the area of the title bar a buffer's name is drawn in, which can
+// be clicked to focus the buffer.
+type titleTab struct {
+	start, end int
+	index      int
+}
+
+// DrawTitleBar draws the editor's title bar at the top of the screen, which
+// lists the names of the open buffers with the focused buffer's name
+// highlighted.
 func (e *Editor) DrawTitleBar() {
 	info := "Atto " + support.AttoVersion
 	localTime := e.uiLocalTime()
-	name := fmt.Sprintf("%v (%v/%v)", e.uiFileName(), e.FocusIndex+1, e.BufferCount())
 
-	// Prepend an asterisk in front of the filename if it is unsaved.
-	if e.FB().IsDirty {
-		name = "*" + name
+	// Prepend an asterisk in front of the names of unsaved buffers.
+	names := make([]string, e.BufferCount())
+	widths := make([]int, e.BufferCount())
+	for i, b := range e.Buffers {
+		if b.IsDirty {
+			names[i] = " *" + e.uiFileName(b) + " "
+		} else {
+			names[i] = " " + e.uiFileName(b) + " "
+		}
+
+		widths[i] = support.StringWidth(names[i])
+	}
+
+	// Calculate the offsets for the time and the names. The time must be
+	// right-aligned, and the names must be centered.
+	infoEnd := support.StringWidth(info) + 1
+	timeOffset := e.Width - support.StringWidth(localTime)
+
+	// Show as many names around the focused buffer's name as there is room for
+	// between the version and the time.
+	first, last := e.FocusIndex, e.FocusIndex
+	width := widths[e.FocusIndex]
+	room := timeOffset - infoEnd - 1
+
+	for grew := true; grew; {
+		grew = false
+
+		if last+1 < len(names) && width+widths[last+1] <= room {
+			last, width, grew = last+1, width+widths[last+1], true
+		}
+
+		if first > 0 && width+widths[first-1] <= room {
+			first, width, grew = first-1, width+widths[first-1], true
+		}
 	}
 
-	// Calculate the offsets for the filename and time. The name must be
-	// centered and the time must be right-aligned.
-	nameOffset := (e.Width - len(name)) / 2
-	timeOffset := e.Width - len(localTime)
+	nameOffset := (e.Width - width) / 2
+	if nameOffset+width > timeOffset-1 {
+		nameOffset = timeOffset - 1 - width
+	}
+	if nameOffset < infoEnd {
+		nameOffset = infoEnd
+	}
 
 	// Draw the bar canvas.
+	fg, bg := e.Theme.TitleBar.Apply(termbox.ColorDefault, termbox.ColorDefault)
 	for x := 0; x < e.Width; x++ {
-		termbox.SetCell(x, 0, ' ', BarForeground, BarBackground)
+		termbox.SetCell(x, 0, ' ', fg, bg)
 	}
 
 	// Draw the bar elements.
-	drawText([]rune(info), 0, 0, BarForeground, BarBackground)
-	drawText([]rune(name), nameOffset, 0, BarForeground, BarBackground)
-	drawText([]rune(localTime), timeOffset, 0, BarForeground, BarBackground)
+	drawText([]rune(info), 0, 0, fg, bg)
+	drawText([]rune(localTime), timeOffset, 0, fg, bg)
+
+	e.tabs = e.tabs[:0]
+	for i, x := first, nameOffset; i <= last; i++ {
+		nameFg, nameBg := fg, bg
+		if i == e.FocusIndex {
+			nameFg, nameBg = e.Theme.CurrentBuffer.Apply(fg, bg)
+		}
+
+		drawText([]rune(names[i]), x, 0, nameFg, nameBg)
+		e.tabs = append(e.tabs, titleTab{x, x + widths[i], i})
+		x += widths[i]
+	}
 }
 
 // statusBarMessage is a shorthand for getting the message for the status bar.
@@ -85,70 +138,249 @@ func (e *Editor) DrawStatusBar() {
 	message := e.statusBarMessage()
 
 	// Format the file info string.
-	info := fmt.Sprintf(" | %v | %v:%v", e.FB().FileType, e.FB().CursorY, e.FB().CursorDX+1)
-	infoOffset := e.Width - len(info)
+	info := fmt.Sprintf(" | %v | %v | %v:%v", e.FB().TypeName(), e.FB().Format, e.FB().CursorY, e.FB().CursorDX+1)
 
-	// Draw the bar canvas.
+	// Show the position of the current match while searching.
+	if e.Search.IsActive {
+		info = fmt.Sprintf(" | %v of %v", e.Search.Index+1, len(e.Search.Matches)) + info
+	}
+
+	infoOffset := e.Width - support.StringWidth(info)
+
+	// Draw the bar canvas, using the prompt's style while it is active.
+	style := e.Theme.StatusBar
+	if e.PromptIsActive {
+		style = e.Theme.Prompt
+	}
+
+	fg, bg := style.Apply(termbox.ColorDefault, termbox.ColorDefault)
 	for x := 0; x < e.Width; x++ {
-		termbox.SetCell(x, e.Height-1, ' ', BarForeground, BarBackground)
+		termbox.SetCell(x, e.Height-1, ' ', fg, bg)
 	}
 
 	// Draw the bar elements.
-	drawText([]rune(message), 0, e.Height-1, BarForeground, BarBackground)
-	drawText([]rune(info), infoOffset, e.Height-1, BarForeground, BarBackground)
+	drawText([]rune(message), 0, e.Height-1, fg, bg)
+	drawText([]rune(info), infoOffset, e.Height-1, fg, bg)
+}
+
+// selectionRange returns the range of rune indices which are selected in a
+// pane's view on the line at index i of its buffer, and whether the line break
+// at its end is selected.
+func selectionRange(p *Pane, i int) (start, end int, lineBreak bool) {
+	selStart, selEnd, ok := p.View.Selection()
+	if !ok || i+1 < selStart.Y || i+1 > selEnd.Y {
+		return 0, 0, false
+	}
+
+	start, end = 0, p.Buffer.Lines[i].Length()
+	if i+1 == selStart.Y {
+		start = selStart.X
+	}
+	if i+1 == selEnd.Y {
+		end = selEnd.X
+	}
+
+	return start, end, i+1 < selEnd.Y
+}
+
+// tokenStyle returns the theme's style for a token type.
+func (e *Editor) tokenStyle(t buffer.TokenType) theme.Style {
+	switch t {
+	case buffer.TokenTypeKeyword:
+		return e.Theme.Keyword
+	case buffer.TokenTypeType:
+		return e.Theme.Type
+	case buffer.TokenTypeNumber:
+		return e.Theme.Number
+	case buffer.TokenTypeString:
+		return e.Theme.String
+	case buffer.TokenTypeComment:
+		return e.Theme.Comment
+	default:
+		return e.Theme.Text
+	}
 }
 
-// DrawBuffer draws the editor's focused buffer.
-func (e *Editor) DrawBuffer() {
-	for y := 0; y < e.Height-2; y++ {
-		i := y + e.FB().OffsetY
+// DrawPane draws a pane and the part of its buffer it shows.
+func (e *Editor) DrawPane(p *Pane) {
+	// The pane's view is kept inside of its buffer in case the buffer was
+	// edited through another pane.
+	p.Buffer.ClampView(&p.View)
 
-		// Return early if we reach the end of the buffer.
-		if i >= e.FB().Length() {
-			return
+	e.ScrollView(p)
+	e.DrawBuffer(p)
+
+	if p.HasBar {
+		e.drawPaneBar(p)
+	}
+}
+
+// drawPaneBar draws the bar below a pane, which shows the name of its buffer.
+func (e *Editor) drawPaneBar(p *Pane) {
+	name := " " + p.Buffer.FileName()
+	if p.Buffer.IsDirty {
+		name = " *" + p.Buffer.FileName()
+	}
+
+	style := e.Theme.InactivePaneBar
+	if p == e.pane {
+		style = e.Theme.PaneBar
+	}
+
+	fg, bg := style.Apply(termbox.ColorDefault, termbox.ColorDefault)
+	for x := 0; x < p.Width; x++ {
+		termbox.SetCell(p.X+x, p.Y+p.Height, ' ', fg, bg)
+	}
+
+	drawText([]rune(name), p.X, p.Y+p.Height, fg, bg)
+}
+
+// DrawBuffer draws the part of a pane's buffer which is in view. While soft
+// wrapping, each line is drawn on as many rows as it wraps onto.
+func (e *Editor) DrawBuffer(p *Pane) {
+	b, v := p.Buffer, &p.View
+	wrap := e.wrapWidth(p)
+
+	y := 0
+	for i := v.OffsetY; i < b.Length() && y < p.Height; i++ {
+		line := &b.Lines[i]
+
+		rows, first := []int{0}, 0
+		if wrap > 0 {
+			rows = line.WrapRows(wrap)
+			if i == v.OffsetY {
+				_, first = topRow(p, wrap)
+			}
 		}
 
-		line := e.FB().Lines[i]
-		length := len(line.DisplayText) - e.FB().OffsetX
+		for r := first; r < len(rows) && y < p.Height; r, y = r+1, y+1 {
+			end, origin := line.Length(), v.OffsetX
+			if r+1 < len(rows) {
+				end = rows[r+1]
+			}
+			if wrap > 0 {
+				origin = line.AdjustedX(rows[r])
+			}
+
+			if r == 0 {
+				e.DrawGutter(p, i, p.Y+y)
+			} else {
+				e.drawWrapGutter(p, i, p.Y+y)
+			}
+
+			e.drawLine(p, i, p.Y+y, rows[r], end, origin)
+		}
+	}
+}
+
+// drawLine draws the runes of the line at index i of a pane's buffer from index
+// start up to index end on screen row sy, with the given display column of the
+// line at the left edge of the text area.
+func (e *Editor) drawLine(p *Pane, i, sy, start, end, origin int) {
+	b := p.Buffer
+	gutter, width := e.GutterWidth(b), e.TextWidth(p)
+	if gutter > p.Width {
+		gutter = p.Width
+	}
+
+	line := &b.Lines[i]
+	selStart, selEnd, lineBreak := selectionRange(p, i)
+
+	// Search matches are only shown for the focused buffer.
+	var matches [][2]int
+	current := -1
+	if b == e.FB() {
+		matches, current = e.matchRanges(i)
+	}
 
-		// Skip to the next line if we have nothing to draw.
-		if length <= 0 {
+	column := 0
+	for x := 0; x < end; x++ {
+		c := line.Runes[x]
+		w := line.ColumnWidth(x, column)
+		sx := column - origin
+		column += w
+
+		// Skip runes which are scrolled out of view or only partially
+		// visible, as well as zero-width runes, which cannot be drawn.
+		if x < start || w == 0 || sx < 0 {
 			continue
+		} else if sx+w > width {
+			break
 		}
 
-		text, tokens := line.DisplayText, line.TokenTypes
-		startIndex, endIndex := e.FB().OffsetX, e.FB().OffsetX+length
+		fg, bg := e.tokenStyle(line.TokenTypes[x]).Apply(termbox.ColorDefault, termbox.ColorDefault)
+		if x >= selStart && x < selEnd {
+			fg, bg = e.Theme.Selection.Apply(fg, bg)
+		}
 
-		for x, c := range text[startIndex:endIndex] {
-			termbox.SetCell(x, y+1, c, tokens[x].Color(), 0)
+		// Highlight search matches, with the current match standing out.
+		for j, m := range matches {
+			if x >= m[0] && x < m[1] && j == current {
+				fg, bg = e.Theme.CurrentMatch.Apply(fg, bg)
+			} else if x >= m[0] && x < m[1] {
+				fg, bg = e.Theme.Match.Apply(fg, bg)
+			}
 		}
+
+		// Tabs are drawn as spaces up to the next tab stop.
+		if c == '\t' {
+			for k := 0; k < w; k++ {
+				termbox.SetCell(p.X+gutter+sx+k, sy, ' ', fg, bg)
+			}
+		} else {
+			termbox.SetCell(p.X+gutter+sx, sy, c, fg, bg)
+		}
+	}
+
+	// Show selected line breaks as a single selected cell past the end of the
+	// line, so that selected empty lines are visible.
+	if sx := column - origin; lineBreak && end == line.Length() && sx >= 0 && sx < width {
+		fg, bg := e.Theme.Selection.Apply(termbox.ColorDefault, termbox.ColorDefault)
+		termbox.SetCell(p.X+gutter+sx, sy, ' ', fg, bg)
 	}
 }
 
-// ScrollView recalculates the offsets for the view window.
-func (e *Editor) ScrollView() {
+// drawSeparators draws the lines separating side by side panes.
+func (e *Editor) drawSeparators() {
+	fg, bg := e.Theme.LineNumber.Apply(termbox.ColorDefault, termbox.ColorDefault)
+	for _, s := range e.separators {
+		for y := s[1]; y < s[1]+s[2]; y++ {
+			termbox.SetCell(s[0], y, '│', fg, bg)
+		}
+	}
+}
 
-	// If the prompt is currently active, everything below can be skipped.
-	if e.PromptIsActive {
+// ScrollView recalculates the offsets of a pane's view so that the cursor is
+// in view.
+func (e *Editor) ScrollView(p *Pane) {
+	v := &p.View
+	line := &p.Buffer.Lines[v.CursorY-1]
+	v.CursorDX = line.AdjustedX(v.CursorX)
+
+	if p.isScrolled {
 		return
 	}
 
-	e.FB().CursorDX = e.FB().FocusedLine().AdjustedX(e.FB().CursorX)
+	if width := e.wrapWidth(p); width > 0 {
+		e.scrollWrapped(p, width)
+		return
+	}
 
-	if e.FB().CursorY-1 < e.FB().OffsetY {
-		e.FB().OffsetY = e.FB().CursorY - 1
+	v.OffsetRow = 0
+	if v.CursorY-1 < v.OffsetY {
+		v.OffsetY = v.CursorY - 1
 	}
 
-	if e.FB().CursorY+2 >= e.FB().OffsetY+e.Height {
-		e.FB().OffsetY = e.FB().CursorY - e.Height + 2
+	if v.CursorY-1 >= v.OffsetY+p.Height {
+		v.OffsetY = v.CursorY - p.Height
 	}
 
-	if e.FB().CursorDX < e.FB().OffsetX {
-		e.FB().OffsetX = e.FB().CursorDX
+	if v.CursorDX < v.OffsetX {
+		v.OffsetX = v.CursorDX
 	}
 
-	if e.FB().CursorDX >= e.FB().OffsetX+e.Width {
-		e.FB().OffsetX = e.FB().CursorDX - e.Width + 1
+	if width := e.TextWidth(p); v.CursorDX >= v.OffsetX+width {
+		v.OffsetX = v.CursorDX - width + 1
 	}
 }
 
@@ -159,7 +391,13 @@ func (e *Editor) Draw() {
 	// The screen's height and width should be updated on each render to account
 	// for the user resizing the window.
 	e.Width, e.Height = termbox.Size()
-	e.ScrollView()
+
+	// Split the area between the title and status bars between the panes.
+	e.syncPanes()
+	panes := e.layout.panes()
+
+	e.separators = nil
+	e.layout.arrange(0, 1, e.Width, e.Height-2, len(panes) > 1, &e.separators)
 
 	err := termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
 	if err != nil {
@@ -167,13 +405,26 @@ func (e *Editor) Draw() {
 	}
 
 	e.DrawTitleBar()
-	e.DrawBuffer()
+	for _, p := range panes {
+		e.DrawPane(p)
+	}
+	e.drawSeparators()
 	e.DrawStatusBar()
 
+	if e.picker != nil {
+		e.DrawPicker()
+	}
+
 	if e.PromptIsActive {
-		termbox.SetCursor(e.FB().CursorX, e.Height)
+		x := support.StringWidth(e.PromptQuestion + string([]rune(e.PromptAnswer)[:e.PromptCursor]))
+		termbox.SetCursor(x, e.Height-1)
 	} else {
-		termbox.SetCursor(e.FB().CursorDX-e.FB().OffsetX, e.FB().CursorY-e.FB().OffsetY)
+		// The cursor may be scrolled out of view with the mouse wheel.
+		if x, y, ok := e.cursorOnScreen(e.pane); ok {
+			termbox.SetCursor(x, y)
+		} else {
+			termbox.HideCursor()
+		}
 	}
 
 	err = termbox.Flush()
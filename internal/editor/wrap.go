@@ -0,0 +1,178 @@
+package editor
+
+import "github.com/jonpalmisc/atto/internal/buffer"
+
+// wrapMarker is shown in the gutter of the rows a wrapped line continues on.
+const wrapMarker = '↪'
+
+// wrapWidth returns the number of columns lines are wrapped at in a pane, which
+// is the width of its text area unless the user's config sets a narrower
+// column, or zero if soft wrapping is off.
+func (e *Editor) wrapWidth(p *Pane) int {
+	if !e.Config.SoftWrap {
+		return 0
+	}
+
+	width := e.TextWidth(p)
+	if c := e.Config.WrapColumn; c > 0 && c < width {
+		width = c
+	}
+
+	if width < 1 {
+		return 1
+	}
+
+	return width
+}
+
+// rowOf returns the row of a wrapped line which the rune at index x is on.
+func rowOf(rows []int, x int) int {
+	r := 0
+	for r+1 < len(rows) && rows[r+1] <= x {
+		r++
+	}
+
+	return r
+}
+
+// rowCount returns the number of rows the line at index i wraps onto.
+func rowCount(b *buffer.Buffer, i, width int) int {
+	return len(b.Lines[i].WrapRows(width))
+}
+
+// walkRows returns the line and row which are n rows below a row of a buffer
+// wrapped at width, or above it if n is negative, stopping at either end of the
+// buffer.
+func walkRows(b *buffer.Buffer, width, i, r, n int) (int, int) {
+	for ; n > 0; n-- {
+		if r+1 < rowCount(b, i, width) {
+			r++
+		} else if i+1 < b.Length() {
+			i, r = i+1, 0
+		} else {
+			break
+		}
+	}
+
+	for ; n < 0; n++ {
+		if r > 0 {
+			r--
+		} else if i > 0 {
+			i--
+			r = rowCount(b, i, width) - 1
+		} else {
+			break
+		}
+	}
+
+	return i, r
+}
+
+// rowsBetween returns the number of rows from one row of a buffer wrapped at
+// width to a later one, counting at most limit rows.
+func rowsBetween(b *buffer.Buffer, width, i, r, toI, toR, limit int) int {
+	n := -r
+	for ; i < toI && n < limit; i++ {
+		n += rowCount(b, i, width)
+	}
+
+	return n + toR
+}
+
+// topRow returns the first row shown in a pane wrapped at width, keeping the
+// view's row offset inside of the first line.
+func topRow(p *Pane, width int) (int, int) {
+	b, v := p.Buffer, &p.View
+
+	i := v.OffsetY
+	if i >= b.Length() {
+		i = b.Length() - 1
+	}
+
+	r := v.OffsetRow
+	if n := rowCount(b, i, width); r >= n {
+		r = n - 1
+	}
+
+	return i, r
+}
+
+// scrollWrapped recalculates the offsets of a pane's view while soft wrapping,
+// so that the row the cursor is on is in view.
+func (e *Editor) scrollWrapped(p *Pane, width int) {
+	b, v := p.Buffer, &p.View
+	v.OffsetX = 0
+
+	cursorI, cursorR := v.CursorY-1, rowOf(b.Lines[v.CursorY-1].WrapRows(width), v.CursorX)
+	topI, topR := topRow(p, width)
+
+	if cursorI < topI || cursorI == topI && cursorR < topR {
+		v.OffsetY, v.OffsetRow = cursorI, cursorR
+		return
+	}
+
+	// If the cursor is below the view, scroll until it is on the last row.
+	if rowsBetween(b, width, topI, topR, cursorI, cursorR, p.Height) >= p.Height {
+		v.OffsetY, v.OffsetRow = walkRows(b, width, cursorI, cursorR, 1-p.Height)
+	} else {
+		v.OffsetY, v.OffsetRow = topI, topR
+	}
+}
+
+// moveRow moves the cursor of a pane up or down by a row of its buffer wrapped
+// at width, keeping it in the same column of the row where possible.
+func (e *Editor) moveRow(p *Pane, delta, width int) {
+	b, v := p.Buffer, &p.View
+
+	line := &b.Lines[v.CursorY-1]
+	rows := line.WrapRows(width)
+	r := rowOf(rows, v.CursorX)
+	column := line.AdjustedX(v.CursorX) - line.AdjustedX(rows[r])
+
+	i, r := walkRows(b, width, v.CursorY-1, r, delta)
+
+	v.CursorY = i + 1
+	line = &b.Lines[i]
+	rows = line.WrapRows(width)
+
+	// Keep the cursor on the row it moved to, rather than past its end, which
+	// is the start of the next row.
+	x := line.IndexAtColumn(line.AdjustedX(rows[r]) + column)
+	if r+1 < len(rows) && x >= rows[r+1] {
+		x = line.PreviousBoundary(rows[r+1])
+	}
+
+	v.CursorX = x
+}
+
+// cursorOnScreen returns the position of the cursor of a pane on the screen,
+// or false if it is scrolled out of view.
+func (e *Editor) cursorOnScreen(p *Pane) (int, int, bool) {
+	b, v := p.Buffer, &p.View
+	x := p.X + e.GutterWidth(b)
+
+	width := e.wrapWidth(p)
+	if width == 0 {
+		y := p.Y + v.CursorY - 1 - v.OffsetY
+		return x + v.CursorDX - v.OffsetX, y, y >= p.Y && y < p.Y+p.Height
+	}
+
+	line := &b.Lines[v.CursorY-1]
+	rows := line.WrapRows(width)
+	r := rowOf(rows, v.CursorX)
+
+	// The cursor may be just past the end of a full row, in which case it is
+	// kept on the row's last column.
+	column := v.CursorDX - line.AdjustedX(rows[r])
+	if column >= width {
+		column = width - 1
+	}
+
+	topI, topR := topRow(p, width)
+	if v.CursorY-1 < topI || v.CursorY-1 == topI && r < topR {
+		return 0, 0, false
+	}
+
+	y := p.Y + rowsBetween(b, width, topI, topR, v.CursorY-1, r, p.Height)
+	return x + column, y, y < p.Y+p.Height
+}
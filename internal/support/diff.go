@@ -0,0 +1,144 @@
+package support
+
+import "fmt"
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// diffLimit is the largest number of line pairs compared to find the smallest
+// set of changes. Larger changes are shown as a removal and an addition.
+const diffLimit = 4000000
+
+// diffLine is a line of a diff, with the indices of the line in the old and
+// new versions of the text.
+type diffLine struct {
+	Kind byte
+	Text string
+	A, B int
+}
+
+// diffLines returns every line of the old and new versions of a text, marked
+// as unchanged (' '), removed ('-') or added ('+').
+func diffLines(a, b []string) []diffLine {
+	var lines []diffLine
+
+	// Skip the lines the versions start and end with in common.
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		lines = append(lines, diffLine{' ', a[start], start, start})
+		start++
+	}
+
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && a[endA-1] == b[endB-1] {
+		endA, endB = endA-1, endB-1
+	}
+
+	n, m := endA-start, endB-start
+	if n*m > diffLimit {
+		for i := start; i < endA; i++ {
+			lines = append(lines, diffLine{'-', a[i], i, start})
+		}
+		for j := start; j < endB; j++ {
+			lines = append(lines, diffLine{'+', b[j], endA, j})
+		}
+	} else {
+
+		// Find the longest common subsequence of the remaining lines, where
+		// lcs[i][j] is its length for the lines after i and j.
+		lcs := make([][]int, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int, m+1)
+		}
+
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if a[start+i] == b[start+j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < n || j < m {
+			ai, bj := start+i, start+j
+			switch {
+			case i < n && j < m && a[ai] == b[bj]:
+				lines = append(lines, diffLine{' ', a[ai], ai, bj})
+				i, j = i+1, j+1
+			case j == m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+				lines = append(lines, diffLine{'-', a[ai], ai, bj})
+				i++
+			default:
+				lines = append(lines, diffLine{'+', b[bj], ai, bj})
+				j++
+			}
+		}
+	}
+
+	for i := endA; i < len(a); i++ {
+		lines = append(lines, diffLine{' ', a[i], i, endB + i - endA})
+	}
+
+	return lines
+}
+
+// Diff compares an old and a new version of a text and returns the changes in
+// the unified diff format. The result is empty if the versions are the same.
+func Diff(oldName, newName string, a, b []string) []string {
+	lines := diffLines(a, b)
+
+	var result []string
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk until the next change is too far away to share its
+		// context with this one.
+		first := i - diffContext
+		if first < 0 {
+			first = 0
+		}
+
+		last := i
+		for k := i; k < len(lines) && k-last <= 2*diffContext; k++ {
+			if lines[k].Kind != ' ' {
+				last = k
+			}
+		}
+
+		end := last + diffContext + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		if result == nil {
+			result = []string{"--- " + oldName, "+++ " + newName}
+		}
+
+		countA, countB := 0, 0
+		for _, l := range lines[first:end] {
+			if l.Kind != '+' {
+				countA++
+			}
+			if l.Kind != '-' {
+				countB++
+			}
+		}
+
+		result = append(result, fmt.Sprintf("@@ -%v,%v +%v,%v @@", lines[first].A+1, countA, lines[first].B+1, countB))
+		for _, l := range lines[first:end] {
+			result = append(result, string(l.Kind)+l.Text)
+		}
+
+		i = end
+	}
+
+	return result
+}
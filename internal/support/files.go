@@ -0,0 +1,92 @@
+package support
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// skippedFolders are folders which are never searched for project files.
+var skippedFolders = map[string]bool{
+	".git":         true,
+	"vendor":       true,
+	"node_modules": true,
+}
+
+// errFileLimit stops the walk once enough files have been found.
+var errFileLimit = errors.New("too many files")
+
+// ProjectFiles lists the text files inside of a folder and its subfolders, as
+// paths relative to the folder. Files ignored by .gitignore files, binary files
+// and the contents of version control and dependency folders are skipped. At
+// most limit files are listed.
+func ProjectFiles(root string, limit int) ([]string, error) {
+	var files []string
+	var rules IgnoreRules
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+
+			// Skip folders and files which cannot be read.
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		// Folders are searched with the rules of their own .gitignore file
+		// added. A .gitignore file which cannot be read is skipped.
+		name := filepath.ToSlash(rel)
+		if name == "." {
+			rules.Load(root, "")
+			return nil
+		}
+
+		if info.IsDir() {
+			if skippedFolders[info.Name()] || rules.IsIgnored(name, true) {
+				return filepath.SkipDir
+			}
+
+			rules.Load(root, name)
+			return nil
+		}
+
+		if !info.Mode().IsRegular() || rules.IsIgnored(name, false) || IsBinaryFile(path) {
+			return nil
+		}
+
+		if files = append(files, rel); len(files) >= limit {
+			return errFileLimit
+		}
+
+		return nil
+	})
+
+	if err == errFileLimit {
+		err = nil
+	}
+
+	return files, err
+}
+
+// IsBinaryFile tells whether a file looks like a binary file, which is the case
+// if its first few kilobytes contain a null byte.
+func IsBinaryFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, 8000)
+	n, _ := file.Read(head)
+
+	return bytes.IndexByte(head[:n], 0) >= 0
+}
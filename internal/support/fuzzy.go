@@ -0,0 +1,92 @@
+package support
+
+import "unicode"
+
+// FuzzyMatch tells whether every rune of a pattern appears in a string in the
+// same order, ignoring case. Matches are scored so that better ones score
+// higher: runes matched consecutively, at the start of words or at the start
+// of the string score extra, while gaps between matched runes cost points. The
+// indices of the matched runes in the string are returned as well.
+func FuzzyMatch(pattern, s string) (score int, positions []int, ok bool) {
+	p, r := []rune(pattern), []rune(s)
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+
+	// Try every place the first rune of the pattern matches as a start, since
+	// the first place is not necessarily the best one.
+	best := -1 << 31
+	for start := range r {
+		if !runesEqual(p[0], r[start]) {
+			continue
+		}
+
+		if sc, pos, matched := fuzzyMatchFrom(p, r, start); matched && sc > best {
+			best, score, positions, ok = sc, sc, pos, true
+		}
+	}
+
+	return score, positions, ok
+}
+
+// fuzzyMatchFrom matches a pattern against a string from a starting index,
+// matching every rune of the pattern as early as possible.
+func fuzzyMatchFrom(p, r []rune, start int) (int, []int, bool) {
+	positions := make([]int, 0, len(p))
+	score := 0
+
+	i := start
+	for _, c := range p {
+		for i < len(r) && !runesEqual(c, r[i]) {
+			i++
+		}
+
+		if i == len(r) {
+			return 0, nil, false
+		}
+
+		// Reward consecutive matches and matches at the start of words, and
+		// penalize the distance from the previous match.
+		if n := len(positions); n > 0 && positions[n-1] == i-1 {
+			score += 8
+		} else if n > 0 {
+			score -= i - positions[n-1] - 1
+		}
+
+		if isWordStart(r, i) {
+			score += 6
+		}
+
+		positions = append(positions, i)
+		i++
+	}
+
+	// Prefer matches which start early, and shorter strings over longer ones.
+	score -= start + len(r)/8
+	if start == 0 {
+		score += 4
+	}
+
+	return score, positions, true
+}
+
+// runesEqual tells whether two runes are equal, ignoring case.
+func runesEqual(a, b rune) bool {
+	return a == b || unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+// isWordStart tells whether the rune at index i starts a word, either because
+// it follows a separator or because it is an upper case letter following a
+// lower case one.
+func isWordStart(r []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	prev := r[i-1]
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+
+	return unicode.IsUpper(r[i]) && unicode.IsLower(prev)
+}
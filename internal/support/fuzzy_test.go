@@ -0,0 +1,72 @@
+package support
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		s         string
+		ok        bool
+		positions []int
+	}{
+		{"", "anything", true, nil},
+		{"abc", "abc", true, []int{0, 1, 2}},
+		{"ABC", "abc", true, []int{0, 1, 2}},
+		{"abc", "ABC", true, []int{0, 1, 2}},
+		{"ac", "abc", true, []int{0, 2}},
+		{"fb", "foo_bar", true, []int{0, 4}},
+		{"bar", "b_bar", true, []int{2, 3, 4}},
+		{"éa", "café bar", true, []int{3, 6}},
+		{"ba", "abc", false, nil},
+		{"abcd", "abc", false, nil},
+		{"x", "", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" in "+tt.s, func(t *testing.T) {
+			_, positions, ok := FuzzyMatch(tt.pattern, tt.s)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !reflect.DeepEqual(positions, tt.positions) {
+				t.Errorf("positions = %v, want %v", positions, tt.positions)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		better  string
+		worse   string
+	}{
+		{"consecutive runes", "abc", "abcxyz", "axbxcx"},
+		{"start of a word", "b", "a_b", "aab"},
+		{"start of a camel case word", "fb", "fooBar", "foobar"},
+		{"start of the string", "foo", "foobar", "barfoo"},
+		{"shorter string", "main", "main.go", "main_test_something_long.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			better, _, ok := FuzzyMatch(tt.pattern, tt.better)
+			if !ok {
+				t.Fatalf("%q does not match %q", tt.pattern, tt.better)
+			}
+
+			worse, _, ok := FuzzyMatch(tt.pattern, tt.worse)
+			if !ok {
+				t.Fatalf("%q does not match %q", tt.pattern, tt.worse)
+			}
+
+			if better <= worse {
+				t.Errorf("%q scores %v in %q, want more than %v in %q", tt.pattern, better, tt.better, worse, tt.worse)
+			}
+		})
+	}
+}
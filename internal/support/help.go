@@ -2,34 +2,57 @@ package support
 
 const AttoVersion string = "0.5.6"
 
-var HelpMessage = []string{
-	"Atto - A lightweight, opinionated text editor written in Go.",
-	"Copyright (c) 2019-2020 Jon Palmisciano",
-	"",
-	"1.  Usage",
-	"",
-	"    $ atto <files>",
-	"",
-	"2.  Shortcuts",
-	"",
-	"    ^R  Open a new buffer",
-	"    ^O  Save the current buffer",
-	"    ^X  Close the current buffer",
-	"",
-	"    ^P  Go to the next buffer",
-	"    ^L  Go to the previous buffer",
-	"",
-	"    ^J  Jump to a specific line",
-	"    ^A  Jump to the beginning of the line",
-	"    ^E  Jump to the end of the line",
+// HelpMessage returns the lines of the help screen, listing the given lines
+// describing the active key bindings and the available commands.
+func HelpMessage(shortcuts, commands []string) []string {
+	lines := []string{
+		"Atto - A lightweight, opinionated text editor written in Go.",
+		"Copyright (c) 2019-2020 Jon Palmisciano",
+		"",
+		"1.  Usage",
+		"",
+		"    $ atto <files>",
+		"",
+		"2.  Shortcuts",
+		"",
+	}
 
-	"    ^C  Cancel the active operation",
-	"    ^H  Show this help screen",
-	"",
-	"3.  Configuration",
-	"",
-	"    A configuration folder has been created for you at '~/.atto'. Inside you ",
-	"    will find the file 'config.yml', which you can edit to change your editor",
-	"    preferences.",
-	"",
+	lines = append(lines, shortcuts...)
+	lines = append(lines,
+		"",
+		"    Holding Ctrl or Alt with the arrows moves the cursor by words and",
+		"    paragraphs, and with Home and End to either end of the buffer. Holding",
+		"    Shift while moving the cursor selects text as well.",
+		"",
+		"    While a prompt or a list is open, ^C cancels it instead of copying. When",
+		"    answering a prompt, Tab completes paths and commands, the arrows Up and",
+		"    Down recall earlier answers, and the usual shell shortcuts edit the",
+		"    answer: ^A/^E or Home/End, ^U, ^K, ^W, and Ctrl/Alt with the arrows to",
+		"    move by words.",
+		"",
+		"3.  Configuration",
+		"",
+		"    A configuration folder has been created for you at '~/.atto'. Inside you ",
+		"    will find the file 'config.yml', which you can edit to change your editor",
+		"    preferences.",
+		"",
+		"    Keys are bound to commands in the 'keybindings' section, for example:",
+		"",
+		"      keybindings:",
+		"        ctrl+s: save",
+		"        ctrl+x ctrl+c: close",
+		"        alt+g: goto",
+		"        f5: set linenumbers relative",
+		"        ctrl+t: none",
+		"",
+		"    Note that many terminals send ^H for Backspace, ^I for Tab and ^M for",
+		"    Enter, so binding those keys may have surprising effects. Terminals",
+		"    which send ^? for Backspace usually send ^H for Ctrl-Backspace, which",
+		"    can be bound with 'ctrl+backspace: deletewordleft' to delete words.",
+		"",
+		"4.  Commands",
+		"",
+	)
+
+	return append(lines, commands...)
 }
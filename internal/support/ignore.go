@@ -0,0 +1,143 @@
+package support
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+)
+
+// ignoreRule is a pattern from a .gitignore file.
+type ignoreRule struct {
+
+	// The folder of the .gitignore file, relative to the project's root and
+	// separated by slashes, and the pattern itself.
+	base    string
+	pattern string
+
+	// Whether the pattern re-includes matching paths, only matches folders, or
+	// is matched against the whole path rather than just the name.
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// IgnoreRules are the rules of the .gitignore files found in a project.
+type IgnoreRules struct {
+	rules []ignoreRule
+}
+
+// Load adds the rules of the .gitignore file in a folder, given relative to
+// the project's root and separated by slashes. Folders without a .gitignore
+// file are skipped.
+func (r *IgnoreRules) Load(root, folder string) error {
+	file, err := os.Open(path.Join(root, folder, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(folder, scanner.Text()); ok {
+			r.rules = append(r.rules, rule)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseIgnoreRule parses a line of a .gitignore file. Blank lines and comments
+// are not rules.
+func parseIgnoreRule(base, line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate, line = true, line[1:]
+	} else if strings.HasPrefix(line, "\\") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+
+	// Patterns with a slash anywhere but at the end are relative to the folder
+	// of the .gitignore file.
+	if strings.Contains(line, "/") {
+		rule.anchored, line = true, strings.TrimPrefix(line, "/")
+	}
+
+	rule.pattern = line
+	return rule, line != ""
+}
+
+// IsIgnored tells whether a path, relative to the project's root and separated
+// by slashes, is ignored. Later rules take precedence over earlier ones.
+func (r *IgnoreRules) IsIgnored(name string, isDir bool) bool {
+	ignored := false
+
+	for _, rule := range r.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		// Rules only apply inside of the folder of their .gitignore file.
+		rel := name
+		if rule.base != "" {
+			if !strings.HasPrefix(name, rule.base+"/") {
+				continue
+			}
+
+			rel = name[len(rule.base)+1:]
+		}
+
+		var matched bool
+		if rule.anchored {
+			matched = matchGlob(strings.Split(rule.pattern, "/"), strings.Split(rel, "/"))
+		} else {
+			matched, _ = path.Match(rule.pattern, path.Base(rel))
+		}
+
+		if matched {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+// matchGlob matches the segments of a path against the segments of a pattern,
+// where a "**" segment matches any number of segments.
+func matchGlob(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchGlob(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return len(segments) == 0
+}
@@ -0,0 +1,104 @@
+package support
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ignoreFile is the contents of a .gitignore file in a folder of a project.
+type ignoreFile struct {
+	folder string
+	lines  []string
+}
+
+// makeIgnoreRules parses the rules of a set of .gitignore files, in the order
+// they would be loaded in.
+func makeIgnoreRules(files ...ignoreFile) *IgnoreRules {
+	r := &IgnoreRules{}
+	for _, f := range files {
+		for _, line := range f.lines {
+			if rule, ok := parseIgnoreRule(f.folder, line); ok {
+				r.rules = append(r.rules, rule)
+			}
+		}
+	}
+
+	return r
+}
+
+func TestIgnoreRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []ignoreFile
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"no rules", nil, "main.go", false, false},
+		{"name", []ignoreFile{{"", []string{"main.go"}}}, "main.go", false, true},
+		{"name in a subfolder", []ignoreFile{{"", []string{"main.go"}}}, "cmd/main.go", false, true},
+		{"glob", []ignoreFile{{"", []string{"*.o"}}}, "lib/x.o", false, true},
+		{"glob not matching", []ignoreFile{{"", []string{"*.o"}}}, "lib/x.go", false, false},
+		{"comment", []ignoreFile{{"", []string{"# main.go"}}}, "main.go", false, false},
+		{"blank line", []ignoreFile{{"", []string{"", "   "}}}, "main.go", false, false},
+		{"trailing spaces", []ignoreFile{{"", []string{"main.go  "}}}, "main.go", false, true},
+		{"escaped comment", []ignoreFile{{"", []string{"\\#notes"}}}, "#notes", false, true},
+		{"folder only on a folder", []ignoreFile{{"", []string{"build/"}}}, "build", true, true},
+		{"folder only on a file", []ignoreFile{{"", []string{"build/"}}}, "build", false, false},
+		{"anchored", []ignoreFile{{"", []string{"/main.go"}}}, "main.go", false, true},
+		{"anchored in a subfolder", []ignoreFile{{"", []string{"/main.go"}}}, "cmd/main.go", false, false},
+		{"path", []ignoreFile{{"", []string{"cmd/main.go"}}}, "cmd/main.go", false, true},
+		{"path in a subfolder", []ignoreFile{{"", []string{"cmd/main.go"}}}, "x/cmd/main.go", false, false},
+		{"double star", []ignoreFile{{"", []string{"**/testdata"}}}, "a/b/testdata", true, true},
+		{"double star at the root", []ignoreFile{{"", []string{"**/testdata"}}}, "testdata", true, true},
+		{"double star in the middle", []ignoreFile{{"", []string{"a/**/z"}}}, "a/b/c/z", false, true},
+		{"double star matching nothing", []ignoreFile{{"", []string{"a/**/z"}}}, "a/z", false, true},
+		{"negated", []ignoreFile{{"", []string{"*.log", "!keep.log"}}}, "keep.log", false, false},
+		{"negated before", []ignoreFile{{"", []string{"!keep.log", "*.log"}}}, "keep.log", false, true},
+		{"nested file", []ignoreFile{{"sub", []string{"*.txt"}}}, "sub/a.txt", false, true},
+		{"nested file outside its folder", []ignoreFile{{"sub", []string{"*.txt"}}}, "a.txt", false, false},
+		{"nested file in a similar folder", []ignoreFile{{"sub", []string{"*.txt"}}}, "subway/a.txt", false, false},
+		{"nested anchored", []ignoreFile{{"sub", []string{"/a.txt"}}}, "sub/a.txt", false, true},
+		{"nested negation", []ignoreFile{{"", []string{"*.txt"}}, {"sub", []string{"!a.txt"}}}, "sub/a.txt", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := makeIgnoreRules(tt.files...)
+			if ignored := r.IsIgnored(tt.path, tt.isDir); ignored != tt.ignored {
+				t.Errorf("IsIgnored(%q, %v) = %v, want %v", tt.path, tt.isDir, ignored, tt.ignored)
+			}
+		})
+	}
+}
+
+func TestIgnoreRulesLoad(t *testing.T) {
+	root, err := ioutil.TempDir("", "atto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "sub", ".gitignore"), []byte("# Objects\r\n*.o\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var r IgnoreRules
+	for _, folder := range []string{"", "sub"} {
+		if err := r.Load(root, folder); err != nil {
+			t.Fatalf("Load(%q) failed: %v", folder, err)
+		}
+	}
+
+	if !r.IsIgnored("sub/x.o", false) {
+		t.Error("sub/x.o is not ignored")
+	}
+	if r.IsIgnored("x.o", false) {
+		t.Error("x.o is ignored")
+	}
+}
@@ -0,0 +1,25 @@
+package support
+
+import "github.com/mattn/go-runewidth"
+
+// RuneWidth returns the number of columns a rune occupies on the screen. This
+// matches how termbox renders runes, which treats ambiguous-width runes as
+// narrow. Zero-width runes, such as combining marks, cannot be drawn on their
+// own and occupy no columns.
+func RuneWidth(r rune) int {
+	w := runewidth.RuneWidth(r)
+	if w == 2 && runewidth.IsAmbiguousWidth(r) {
+		return 1
+	}
+
+	return w
+}
+
+// StringWidth returns the number of columns a string occupies on the screen.
+func StringWidth(s string) (width int) {
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+
+	return width
+}
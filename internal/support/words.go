@@ -0,0 +1,102 @@
+package support
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words describes which runes words are made of, and how they are split when
+// moving by words. Words are made up of letters, digits and the extra runes.
+// If Subwords is set, words written in camelCase are split into their parts.
+type Words struct {
+	Extra    string
+	Subwords bool
+}
+
+// DefaultWords are the words used unless configured otherwise, made up of
+// letters, digits and underscores.
+var DefaultWords = Words{Extra: "_"}
+
+// IsWordRune tells whether a rune is part of a word.
+func (w Words) IsWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(w.Extra, r)
+}
+
+// isSubwordStart tells whether a part of a camelCase word starts at index i,
+// which is where an uppercase letter follows a lowercase letter or digit, or
+// where the last of a run of uppercase letters is followed by a lowercase one,
+// as in "parseHTTPRequest". A part also starts where a letter or digit follows
+// one of the extra runes, as in "snake_case".
+func isSubwordStart(runes []rune, i int) bool {
+	if i == 0 || i >= len(runes) {
+		return false
+	}
+
+	prev, c := runes[i-1], runes[i]
+	isAlnum := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+	switch {
+	case !isAlnum(prev):
+		return isAlnum(c)
+	case !unicode.IsUpper(c):
+		return false
+	case unicode.IsLower(prev), unicode.IsDigit(prev):
+		return true
+	}
+
+	return unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+}
+
+// PreviousWordStart returns the index of the start of the word before index i,
+// skipping any other runes in between.
+func (w Words) PreviousWordStart(runes []rune, i int) int {
+	for i > 0 && !w.IsWordRune(runes[i-1]) {
+		i--
+	}
+
+	for i > 0 && w.IsWordRune(runes[i-1]) {
+		i--
+
+		if w.Subwords && isSubwordStart(runes, i) {
+			break
+		}
+	}
+
+	return i
+}
+
+// NextWordEnd returns the index just past the end of the word after index i,
+// skipping any other runes in between.
+func (w Words) NextWordEnd(runes []rune, i int) int {
+	for i < len(runes) && !w.IsWordRune(runes[i]) {
+		i++
+	}
+
+	for i < len(runes) && w.IsWordRune(runes[i]) {
+		i++
+
+		if w.Subwords && isSubwordStart(runes, i) {
+			break
+		}
+	}
+
+	return i
+}
+
+// IsWordRune tells whether a rune is part of a word. Words are made up of
+// letters, digits and underscores.
+func IsWordRune(r rune) bool {
+	return DefaultWords.IsWordRune(r)
+}
+
+// PreviousWordStart returns the index of the start of the word before index i,
+// skipping any other runes in between.
+func PreviousWordStart(runes []rune, i int) int {
+	return DefaultWords.PreviousWordStart(runes, i)
+}
+
+// NextWordEnd returns the index just past the end of the word after index i,
+// skipping any other runes in between.
+func NextWordEnd(runes []rune, i int) int {
+	return DefaultWords.NextWordEnd(runes, i)
+}
@@ -0,0 +1,73 @@
+package syntax
+
+// LanguageC defines the syntax of the C language.
+var LanguageC = Syntax{
+	Name:  "C",
+	Files: []string{"*.c", "*.h", "*.cpp", "*.hpp", "*.cc"},
+	Keywords: []string{
+		"#define", "#elif", "#else", "#endif", "#if", "#ifdef", "#ifndef",
+		"#include", "#pragma", "#undef", "NULL", "auto", "break", "case",
+		"const", "continue", "default", "do", "else", "enum", "extern", "for",
+		"goto", "if", "register", "return", "sizeof", "static", "struct",
+		"switch", "typedef", "union", "volatile", "while",
+	},
+	Types: []string{
+		"char", "double", "float", "int", "long", "short", "signed", "unsigned",
+		"void",
+	},
+	Patterns: Patterns{
+		SingleLineCommentStart: "//",
+		MultiLineCommentStart:  "/*",
+		MultiLineCommentEnd:    "*/",
+		StringDelimiters:       "\"'",
+		EscapeCharacter:        "\\",
+	},
+	Numbers: Numbers{
+		Enabled:  true,
+		AllowHex: true,
+		Suffixes: "uUlLfF",
+	},
+	Indent: Indent{
+		Openers: []string{"{", "(", "["},
+		Closers: "})]",
+	},
+	Pairs: "()[]{}\"\"''",
+}
+
+// LanguageGo defines the syntax of the Go language.
+var LanguageGo = Syntax{
+	Name:  "Go",
+	Files: []string{"*.go"},
+	Keywords: []string{
+		"append", "break", "cap", "case", "chan", "close", "complex", "const",
+		"continue", "copy", "default", "defer", "delete", "else", "fallthrough",
+		"false", "for", "func", "go", "goto", "if", "imag", "import",
+		"interface", "len", "make", "map", "new", "nil", "package", "panic",
+		"range", "real", "recover", "return", "select", "struct", "switch",
+		"true", "type", "var",
+	},
+	Types: []string{
+		"bool", "byte", "complex128", "complex64", "error", "float32",
+		"float64", "int", "int16", "int32", "int64", "int8", "rune", "string",
+		"uint", "uint16", "uint32", "uint64", "uint8", "uintptr",
+	},
+	Patterns: Patterns{
+		SingleLineCommentStart: "//",
+		MultiLineCommentStart:  "/*",
+		MultiLineCommentEnd:    "*/",
+		StringDelimiters:       "\"'",
+		EscapeCharacter:        "\\",
+		RawStringDelimiters:    "`",
+	},
+	Numbers: Numbers{
+		Enabled:    true,
+		AllowHex:   true,
+		Separators: "_",
+		Suffixes:   "i",
+	},
+	Indent: Indent{
+		Openers: []string{"{", "(", "["},
+		Closers: "})]",
+	},
+	Pairs: "()[]{}\"\"''``",
+}
@@ -0,0 +1,98 @@
+package syntax
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// registry holds every known syntax, in order of priority.
+var registry []*Syntax
+
+func init() {
+	Register(&LanguageC)
+	Register(&LanguageGo)
+}
+
+// Register adds a syntax to the registry. A syntax with the same name as one
+// already registered replaces it, and newly registered syntaxes take priority
+// over older ones when matching file names.
+func Register(s *Syntax) {
+	for i, r := range registry {
+		if strings.EqualFold(r.Name, s.Name) {
+			registry = append(registry[:i], registry[i+1:]...)
+			break
+		}
+	}
+
+	registry = append([]*Syntax{s}, registry...)
+}
+
+// ForFile returns the syntax for a file based on its name, or nil if no
+// registered syntax applies to the file.
+func ForFile(path string) *Syntax {
+	name := filepath.Base(path)
+
+	for _, s := range registry {
+		for _, pattern := range s.Files {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return s
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadFile reads a syntax definition from a YAML file.
+func LoadFile(path string) (*Syntax, error) {
+	yml, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := Default()
+	err = yaml.UnmarshalStrict(yml, &s)
+	if err != nil {
+		return nil, fmt.Errorf("%v (%v)", filepath.Base(path), err)
+	}
+
+	// Files without a name are named after the file they are defined in.
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return &s, nil
+}
+
+// LoadFolder registers every syntax definition in a folder, overriding the
+// built-in definitions where they share a name. Files which fail to load are
+// skipped and their errors returned.
+func LoadFolder(path string) (errs []error) {
+	files, err := ioutil.ReadDir(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return []error{err}
+	}
+
+	for _, f := range files {
+		if ext := filepath.Ext(f.Name()); f.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+
+		s, err := LoadFile(filepath.Join(path, f.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		Register(s)
+	}
+
+	return errs
+}
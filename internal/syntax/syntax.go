@@ -2,52 +2,181 @@ package syntax
 
 // Patterns is used to define syntax patterns for the highlighter.
 type Patterns struct {
-	SingleLineCommentStart string
-	MultiLineCommentStart  string
-	MultiLineCommentEnd    string
+	SingleLineCommentStart string `yaml:"line_comment"`
+	MultiLineCommentStart  string `yaml:"block_comment_start"`
+	MultiLineCommentEnd    string `yaml:"block_comment_end"`
+
+	// The runes which start and end strings, and the rune used to escape them
+	// inside of strings.
+	StringDelimiters string `yaml:"string_delimiters"`
+	EscapeCharacter  string `yaml:"escape_character"`
+
+	// The runes which start and end raw strings, which may span multiple
+	// lines and do not support escapes.
+	RawStringDelimiters string `yaml:"raw_string_delimiters"`
+}
+
+// Numbers defines how numbers are recognized by the highlighter.
+type Numbers struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Whether hexadecimal numbers prefixed with "0x" are allowed.
+	AllowHex bool `yaml:"allow_hex"`
+
+	// The runes which may separate digits, such as underscores, and the runes
+	// which may end a number, such as type suffixes.
+	Separators string `yaml:"separators"`
+	Suffixes   string `yaml:"suffixes"`
+}
+
+// Indent defines how lines are indented as they are typed.
+type Indent struct {
+
+	// The strings which, when a line ends with them, indent the lines after it
+	// by another level, such as "{" or ":".
+	Openers []string `yaml:"openers"`
+
+	// The runes which, when typed at the start of a line, take away a level
+	// of its indentation, such as "}".
+	Closers string `yaml:"closers"`
 }
 
 // Syntax represent's a language syntax for highlighting purposes.
 type Syntax struct {
-	Keywords []string
-	Patterns Patterns
-}
-
-// Syntax definitions are temporarily hardcoded until support for language
-// definition files is added!
-
-// LanguageC defines the syntax of the C language.
-var LanguageC = Syntax{
-	Keywords: []string{
-		"#define", "#include", "NULL", "auto", "break", "case", "char", "const",
-		"continue", "default", "do", "double", "else", "enum", "extern", "float",
-		"for", "goto", "if", "int", "long", "register", "return", "short",
-		"signed", "sizeof", "static", "struct", "switch", "typedef", "union",
-		"unsigned", "void", "volatile", "while",
-	},
-	Patterns: Patterns{
-		SingleLineCommentStart: "//",
-		MultiLineCommentStart:  "/*",
-		MultiLineCommentEnd:    "*/",
-	},
-}
-
-// LanguageGo defines the syntax of the Go language.
-var LanguageGo = Syntax{
-	Keywords: []string{
-		"append", "bool", "break", "byte", "cap", "case", "chan", "close",
-		"complex", "complex128", "complex64", "const", "continue", "copy",
-		"default", "defer", "delete", "else", "error", "fallthrough", "false",
-		"float32", "float64", "for", "func", "go", "goto", "if", "imag",
-		"import", "int", "int16", "int32", "int64", "int8", "interface", "len",
-		"make", "map", "new", "nil", "package", "panic", "range", "real",
-		"recover", "return", "rune", "select", "string", "struct", "switch",
-		"true", "type", "uint", "uint16", "uint32", "uint64", "uint8", "uintptr",
-		"var",
-	},
-	Patterns: Patterns{
-		SingleLineCommentStart: "//",
-		MultiLineCommentStart:  "/*",
-		MultiLineCommentEnd:    "*/",
-	},
+	Name string `yaml:"name"`
+
+	// Glob patterns matching the names of the files the syntax applies to,
+	// such as "*.go" or "Makefile".
+	Files []string `yaml:"files"`
+
+	Keywords []string `yaml:"keywords"`
+	Types    []string `yaml:"types"`
+	Patterns Patterns `yaml:"patterns"`
+	Numbers  Numbers  `yaml:"numbers"`
+	Indent   Indent   `yaml:"indent"`
+
+	// The runes which are typed in pairs, each opening rune followed by the
+	// rune which closes it, such as "()[]{}". Closing runes are inserted
+	// along with the opening ones when auto-pairing is enabled.
+	Pairs string `yaml:"pairs"`
+
+	// Sets built from the keywords and types for faster lookups.
+	keywordSet map[string]bool
+	typeSet    map[string]bool
+}
+
+// Default returns a syntax with the default patterns and number rules, which
+// definitions loaded from files are applied on top of.
+func Default() Syntax {
+	return Syntax{
+		Patterns: Patterns{
+			StringDelimiters: "\"'",
+			EscapeCharacter:  "\\",
+		},
+		Numbers: Numbers{
+			Enabled: true,
+		},
+		Indent: Indent{
+			Openers: []string{"{", "(", "["},
+			Closers: "})]",
+		},
+		Pairs: "()[]{}\"\"''",
+	}
+}
+
+// buildSets builds the keyword and type sets if they have not been built yet.
+func (s *Syntax) buildSets() {
+	if s.keywordSet != nil {
+		return
+	}
+
+	s.keywordSet = make(map[string]bool, len(s.Keywords))
+	for _, k := range s.Keywords {
+		s.keywordSet[k] = true
+	}
+
+	s.typeSet = make(map[string]bool, len(s.Types))
+	for _, t := range s.Types {
+		s.typeSet[t] = true
+	}
+}
+
+// IsKeyword tells whether a word is one of the syntax's keywords.
+func (s *Syntax) IsKeyword(word string) bool {
+	s.buildSets()
+	return s.keywordSet[word]
+}
+
+// IsType tells whether a word is one of the syntax's types.
+func (s *Syntax) IsType(word string) bool {
+	s.buildSets()
+	return s.typeSet[word]
+}
+
+// IsStringDelimiter tells whether a rune starts and ends strings.
+func (s *Syntax) IsStringDelimiter(r rune) bool {
+	for _, d := range s.Patterns.StringDelimiters {
+		if r == d {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsRawStringDelimiter tells whether a rune starts and ends raw strings.
+func (s *Syntax) IsRawStringDelimiter(r rune) bool {
+	for _, d := range s.Patterns.RawStringDelimiters {
+		if r == d {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsEscape tells whether a rune escapes the next rune inside of strings.
+func (s *Syntax) IsEscape(r rune) bool {
+	for _, e := range s.Patterns.EscapeCharacter {
+		return r == e
+	}
+
+	return false
+}
+
+// IsIndentCloser tells whether a rune takes away a level of indentation when
+// typed at the start of a line.
+func (s *Syntax) IsIndentCloser(r rune) bool {
+	for _, c := range s.Indent.Closers {
+		if r == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PairCloser returns the rune which closes a pair opened by a rune, if the rune
+// opens a pair.
+func (s *Syntax) PairCloser(r rune) (rune, bool) {
+	pairs := []rune(s.Pairs)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i] == r {
+			return pairs[i+1], true
+		}
+	}
+
+	return 0, false
+}
+
+// IsPairCloser tells whether a rune closes a pair.
+func (s *Syntax) IsPairCloser(r rune) bool {
+	pairs := []rune(s.Pairs)
+	for i := 1; i < len(pairs); i += 2 {
+		if pairs[i] == r {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,145 @@
+package theme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// colorKind is the way a color is specified in a theme.
+type colorKind int
+
+const (
+	colorDefault colorKind = iota
+	colorPalette
+	colorRGB
+)
+
+// color is a color as specified in a theme, before it is resolved for an
+// output mode.
+type color struct {
+	kind    colorKind
+	index   int
+	r, g, b uint8
+}
+
+// namedColors maps color names to their index in the 256-color palette.
+var namedColors = map[string]int{
+	"black":          0,
+	"red":            1,
+	"green":          2,
+	"yellow":         3,
+	"blue":           4,
+	"magenta":        5,
+	"cyan":           6,
+	"white":          7,
+	"gray":           8,
+	"bright-red":     9,
+	"bright-green":   10,
+	"bright-yellow":  11,
+	"bright-blue":    12,
+	"bright-magenta": 13,
+	"bright-cyan":    14,
+	"bright-white":   15,
+}
+
+// parseColor parses a color name, a 256-color palette index or a 24-bit color
+// in hexadecimal notation, such as "#ff8800".
+func parseColor(s string) (color, error) {
+	if s == "default" {
+		return color{kind: colorDefault}, nil
+	}
+
+	if i, ok := namedColors[s]; ok {
+		return color{kind: colorPalette, index: i}, nil
+	}
+
+	if strings.HasPrefix(s, "#") && len(s) == 7 {
+		v, err := strconv.ParseUint(s[1:], 16, 32)
+		if err == nil {
+			return color{kind: colorRGB, r: uint8(v >> 16), g: uint8(v >> 8), b: uint8(v)}, nil
+		}
+	}
+
+	if i, err := strconv.Atoi(s); err == nil && i >= 0 && i < 256 {
+		return color{kind: colorPalette, index: i}, nil
+	}
+
+	return color{}, fmt.Errorf("invalid color \"%v\"", s)
+}
+
+// standardRGB holds the RGB values of the 16 standard colors, as used by xterm.
+var standardRGB = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels holds the levels of each component in the 256-color palette's
+// 6x6x6 color cube.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// paletteRGB returns the RGB values of a color in the 256-color palette.
+func paletteRGB(i int) (r, g, b uint8) {
+	switch {
+	case i < 16:
+		return standardRGB[i][0], standardRGB[i][1], standardRGB[i][2]
+	case i < 232:
+		i -= 16
+		return cubeLevels[i/36], cubeLevels[i/6%6], cubeLevels[i%6]
+	default:
+		v := uint8(8 + (i-232)*10)
+		return v, v, v
+	}
+}
+
+// nearestPaletteColor returns the index of the color closest to the given RGB
+// values among the first n colors of the 256-color palette.
+func nearestPaletteColor(r, g, b uint8, n int) int {
+	best, bestDistance := 0, -1
+
+	for i := 0; i < n; i++ {
+		pr, pg, pb := paletteRGB(i)
+		dr, dg, db := int(r)-int(pr), int(g)-int(pg), int(b)-int(pb)
+
+		if d := dr*dr + dg*dg + db*db; bestDistance < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+
+	return best
+}
+
+// attribute resolves the color into a termbox attribute for an output mode.
+// Colors which cannot be shown in the output mode are approximated.
+func (c color) attribute(mode termbox.OutputMode) termbox.Attribute {
+	if c.kind == colorDefault {
+		return termbox.ColorDefault
+	}
+
+	r, g, b := c.r, c.g, c.b
+	if c.kind == colorPalette {
+		r, g, b = paletteRGB(c.index)
+	}
+
+	switch mode {
+	case termbox.OutputRGB:
+		return termbox.RGBToAttribute(r, g, b)
+	case termbox.Output256:
+		if c.kind == colorPalette {
+			return termbox.Attribute(c.index + 1)
+		}
+
+		return termbox.Attribute(nearestPaletteColor(r, g, b, 256) + 1)
+	default:
+		i := c.index
+		if c.kind == colorRGB || i >= 16 {
+			i = nearestPaletteColor(r, g, b, 16)
+		}
+
+		return termbox.ColorBlack + termbox.Attribute(i)
+	}
+}
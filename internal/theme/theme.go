@@ -0,0 +1,283 @@
+package theme
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+	"gopkg.in/yaml.v2"
+)
+
+// Style is a resolved theme style. Styles used as overlays, such as the style
+// for selected text, only replace the colors they set, and their attributes are
+// added to the foreground color underneath.
+type Style struct {
+	Foreground termbox.Attribute
+	Background termbox.Attribute
+	Attributes termbox.Attribute
+
+	SetsForeground bool
+	SetsBackground bool
+
+	// Whether the attributes can be added to the default foreground color. In
+	// the 24-bit output mode, a default color with attributes would be drawn
+	// as black, so the attributes are left out instead.
+	attributesOnDefault bool
+}
+
+// Apply returns the colors of a cell drawn with the style on top of the given
+// colors.
+func (s Style) Apply(fg, bg termbox.Attribute) (termbox.Attribute, termbox.Attribute) {
+	if s.SetsForeground {
+		fg = s.Foreground
+	}
+	if s.SetsBackground {
+		bg = s.Background
+	}
+
+	if fg != termbox.ColorDefault || s.attributesOnDefault {
+		fg |= s.Attributes
+	}
+
+	return fg, bg
+}
+
+// attributes maps attribute names to termbox attributes.
+var attributes = map[string]termbox.Attribute{
+	"bold":      termbox.AttrBold,
+	"underline": termbox.AttrUnderline,
+	"italic":    termbox.AttrCursive,
+	"reverse":   termbox.AttrReverse,
+	"dim":       termbox.AttrDim,
+}
+
+// parseStyle parses a style written as "<fg> [on <bg>] [attributes...]", such
+// as "black on white bold", and resolves it for an output mode. Either color
+// may be omitted, as in "on blue".
+func parseStyle(s string, mode termbox.OutputMode) (Style, error) {
+	style := Style{attributesOnDefault: mode != termbox.OutputRGB}
+
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		if attr, ok := attributes[field]; ok {
+			style.Attributes |= attr
+			continue
+		}
+
+		if field == "on" {
+			if i+1 == len(fields) {
+				return Style{}, fmt.Errorf("missing background color in \"%v\"", s)
+			}
+
+			c, err := parseColor(fields[i+1])
+			if err != nil {
+				return Style{}, err
+			}
+
+			style.Background, style.SetsBackground = c.attribute(mode), true
+			i++
+			continue
+		}
+
+		c, err := parseColor(field)
+		if err != nil {
+			return Style{}, err
+		}
+
+		style.Foreground, style.SetsForeground = c.attribute(mode), true
+	}
+
+	return style, nil
+}
+
+// Theme is a set of styles for every element of the editor, as written in a
+// theme file.
+type Theme struct {
+	Text    string `yaml:"text"`
+	Keyword string `yaml:"keyword"`
+	Type    string `yaml:"type"`
+	Number  string `yaml:"number"`
+	String  string `yaml:"string"`
+	Comment string `yaml:"comment"`
+
+	TitleBar      string `yaml:"title_bar"`
+	CurrentBuffer string `yaml:"current_buffer"`
+	StatusBar     string `yaml:"status_bar"`
+	Prompt        string `yaml:"prompt"`
+
+	PaneBar         string `yaml:"pane_bar"`
+	InactivePaneBar string `yaml:"inactive_pane_bar"`
+
+	Selection    string `yaml:"selection"`
+	Match        string `yaml:"match"`
+	CurrentMatch string `yaml:"current_match"`
+
+	LineNumber        string `yaml:"line_number"`
+	CurrentLineNumber string `yaml:"current_line_number"`
+}
+
+// Default returns the default theme, which only uses the 16 standard colors.
+func Default() Theme {
+	return Theme{
+		Text:    "default",
+		Keyword: "magenta",
+		Type:    "yellow",
+		Number:  "blue",
+		String:  "green",
+		Comment: "cyan",
+
+		TitleBar:      "black on white",
+		CurrentBuffer: "black on cyan",
+		StatusBar:     "black on white",
+		Prompt:        "black on white",
+
+		PaneBar:         "black on white",
+		InactivePaneBar: "white on gray",
+
+		Selection:    "black on white",
+		Match:        "black on yellow",
+		CurrentMatch: "black on cyan",
+
+		LineNumber:        "gray",
+		CurrentLineNumber: "default",
+	}
+}
+
+// Styles is a theme with its styles resolved for an output mode.
+type Styles struct {
+	Text    Style
+	Keyword Style
+	Type    Style
+	Number  Style
+	String  Style
+	Comment Style
+
+	TitleBar      Style
+	CurrentBuffer Style
+	StatusBar     Style
+	Prompt        Style
+
+	PaneBar         Style
+	InactivePaneBar Style
+
+	Selection    Style
+	Match        Style
+	CurrentMatch Style
+
+	LineNumber        Style
+	CurrentLineNumber Style
+}
+
+// Resolve resolves every style of the theme for an output mode.
+func (t *Theme) Resolve(mode termbox.OutputMode) (Styles, error) {
+	var styles Styles
+
+	pairs := []struct {
+		dst *Style
+		src string
+	}{
+		{&styles.Text, t.Text},
+		{&styles.Keyword, t.Keyword},
+		{&styles.Type, t.Type},
+		{&styles.Number, t.Number},
+		{&styles.String, t.String},
+		{&styles.Comment, t.Comment},
+		{&styles.TitleBar, t.TitleBar},
+		{&styles.CurrentBuffer, t.CurrentBuffer},
+		{&styles.StatusBar, t.StatusBar},
+		{&styles.Prompt, t.Prompt},
+		{&styles.PaneBar, t.PaneBar},
+		{&styles.InactivePaneBar, t.InactivePaneBar},
+		{&styles.Selection, t.Selection},
+		{&styles.Match, t.Match},
+		{&styles.CurrentMatch, t.CurrentMatch},
+		{&styles.LineNumber, t.LineNumber},
+		{&styles.CurrentLineNumber, t.CurrentLineNumber},
+	}
+
+	for _, p := range pairs {
+		style, err := parseStyle(p.src, mode)
+		if err != nil {
+			return Styles{}, err
+		}
+
+		*p.dst = style
+	}
+
+	return styles, nil
+}
+
+// Load loads a theme by name from a folder of theme files. Styles which are
+// missing from the theme file are taken from the default theme. The name
+// "default" always refers to the default theme.
+func Load(folder, name string) (Theme, error) {
+	if name == "" || name == "default" {
+		return Default(), nil
+	}
+
+	yml, err := ioutil.ReadFile(filepath.Join(folder, name+".yml"))
+	if os.IsNotExist(err) {
+		return Default(), fmt.Errorf("theme \"%v\" not found", name)
+	} else if err != nil {
+		return Default(), err
+	}
+
+	t := Default()
+	err = yaml.UnmarshalStrict(yml, &t)
+	if err != nil {
+		return Default(), fmt.Errorf("%v (%v)", name, err)
+	}
+
+	return t, nil
+}
+
+// usesRGB tells whether any of the theme's styles uses a 24-bit color.
+func (t *Theme) usesRGB() bool {
+	styles := []string{
+		t.Text, t.Keyword, t.Type, t.Number, t.String, t.Comment,
+		t.TitleBar, t.CurrentBuffer, t.StatusBar, t.Prompt,
+		t.PaneBar, t.InactivePaneBar,
+		t.Selection, t.Match, t.CurrentMatch,
+		t.LineNumber, t.CurrentLineNumber,
+	}
+
+	for _, s := range styles {
+		for _, field := range strings.Fields(s) {
+			if c, err := parseColor(field); err == nil && c.kind == colorRGB {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// OutputMode returns the termbox output mode for a color mode setting, which
+// is one of "16", "256", "truecolor" or "auto", and a theme. The "auto" setting
+// picks a mode based on the terminal's environment variables, and only picks
+// the 24-bit mode for themes with 24-bit colors, since named colors are drawn
+// with fixed values in that mode instead of the terminal's own palette.
+func OutputMode(setting string, t *Theme) termbox.OutputMode {
+	switch setting {
+	case "16":
+		return termbox.OutputNormal
+	case "256":
+		return termbox.Output256
+	case "truecolor":
+		return termbox.OutputRGB
+	}
+
+	truecolor := os.Getenv("COLORTERM") == "truecolor" || os.Getenv("COLORTERM") == "24bit"
+	if truecolor && t.usesRGB() {
+		return termbox.OutputRGB
+	} else if truecolor || strings.Contains(os.Getenv("TERM"), "256color") {
+		return termbox.Output256
+	}
+
+	return termbox.OutputNormal
+}